@@ -0,0 +1,109 @@
+// Command otlp-collector is a local stand-in for an OpenTelemetry collector.
+// It accepts OTLP/HTTP JSON trace exports on /v1/traces and prints one line per span,
+// so the gateway OTLP exporter can be checked without running a real collector.
+//
+//	go run ./cmd/otlp-collector -addr :4318
+//	TRACING_EXPORTER=otlp TRACING_OTLP_ENDPOINT=http://localhost:4318/v1/traces go run ./cmd/server
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// exportRequest is the subset of ExportTraceServiceRequest printed by the collector
+type exportRequest struct {
+	ResourceSpans []struct {
+		Resource struct {
+			Attributes []keyValue `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []struct {
+			Spans []struct {
+				TraceID           string     `json:"traceId"`
+				SpanID            string     `json:"spanId"`
+				ParentSpanID      string     `json:"parentSpanId"`
+				Name              string     `json:"name"`
+				Kind              int        `json:"kind"`
+				StartTimeUnixNano string     `json:"startTimeUnixNano"`
+				EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+				Attributes        []keyValue `json:"attributes"`
+				Status            struct {
+					Code    int    `json:"code"`
+					Message string `json:"message"`
+				} `json:"status"`
+			} `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+type keyValue struct {
+	Key   string                     `json:"key"`
+	Value map[string]json.RawMessage `json:"value"`
+}
+
+func (kv keyValue) String() string {
+	for _, raw := range kv.Value {
+		return kv.Key + "=" + strings.Trim(string(raw), `"`)
+	}
+	return kv.Key + "="
+}
+
+func main() {
+	addr := flag.String("addr", ":4318", "listen address")
+	raw := flag.Bool("raw", false, "print the request bodies instead of one line per span")
+	flag.Parse()
+
+	http.HandleFunc("POST /v1/traces", func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+			http.Error(w, "only OTLP/HTTP JSON is supported", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		var req exportRequest
+		if *raw {
+			var body json.RawMessage
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintln(os.Stdout, string(body))
+		} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		for _, rs := range req.ResourceSpans {
+			service := ""
+			for _, attr := range rs.Resource.Attributes {
+				if attr.Key == "service.name" {
+					service = strings.TrimPrefix(attr.String(), "service.name=")
+				}
+			}
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					start, _ := strconv.ParseInt(span.StartTimeUnixNano, 10, 64)
+					end, _ := strconv.ParseInt(span.EndTimeUnixNano, 10, 64)
+					attrs := make([]string, 0, len(span.Attributes))
+					for _, attr := range span.Attributes {
+						attrs = append(attrs, attr.String())
+					}
+					fmt.Printf("%s trace=%s span=%s parent=%s kind=%d %q %.3fms status=%d %s\n",
+						service, span.TraceID, span.SpanID, span.ParentSpanID, span.Kind, span.Name,
+						float64(end-start)/1e6, span.Status.Code, strings.Join(attrs, " "))
+				}
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+
+	log.Printf("OTLP collector stand-in listening on %s (POST /v1/traces)", *addr)
+	log.Fatal(http.ListenAndServe(*addr, nil))
+}
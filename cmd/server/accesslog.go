@@ -0,0 +1,50 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/accesslog"
+	"github.com/thatlq1812/service-3-gateway/internal/rotate"
+)
+
+// loadAccessLog builds the access logger from environment.
+// ACCESS_LOG_FILE empty disables it (nil logger), "stdout" writes to standard output.
+// The returned closer flushes rotated files and must be closed on shutdown, it is nil for stdout.
+func loadAccessLog() (*accesslog.Logger, io.Closer, error) {
+	path := getEnv("ACCESS_LOG_FILE", "")
+	if path == "" {
+		return nil, nil, nil
+	}
+
+	sampleRate, err := strconv.ParseFloat(getEnv("ACCESS_LOG_SAMPLE_RATE", "1"), 64)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if path == "stdout" {
+		logger, err := accesslog.New(os.Stdout, getEnv("ACCESS_LOG_FORMAT", accesslog.FormatCombined), sampleRate)
+		return logger, nil, err
+	}
+
+	w, err := rotate.Open(rotate.Config{
+		Path:       path,
+		MaxSize:    int64(getEnvInt("ACCESS_LOG_MAX_SIZE_MB", 100)) << 20,
+		Interval:   getEnvDuration("ACCESS_LOG_ROTATE_INTERVAL", 24*time.Hour),
+		Compress:   getEnvBool("ACCESS_LOG_COMPRESS", true),
+		MaxBackups: getEnvInt("ACCESS_LOG_MAX_FILES", 14),
+		MaxAge:     getEnvDuration("ACCESS_LOG_MAX_AGE", 30*24*time.Hour),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logger, err := accesslog.New(w, getEnv("ACCESS_LOG_FORMAT", accesslog.FormatCombined), sampleRate)
+	if err != nil {
+		w.Close()
+		return nil, nil, err
+	}
+	return logger, w, nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/thatlq1812/service-3-gateway/internal/cache"
+	"github.com/thatlq1812/service-3-gateway/internal/diag"
+	"github.com/thatlq1812/service-3-gateway/internal/history"
+	"github.com/thatlq1812/service-3-gateway/internal/logging"
+	"github.com/thatlq1812/service-3-gateway/internal/metrics"
+)
+
+// adminDeps are the gateway components exposed on the admin listener
+type adminDeps struct {
+	metrics  *metrics.Gateway
+	logLevel *slog.LevelVar
+	timeline *history.Timeline
+	cache    *cache.Cache // Nil when the response cache is disabled
+
+	// Debug endpoints (pprof, channelz, goroutines) are only served when a secret is set,
+	// and requests changing gateway state (log level, cache purge) must present it
+	debugSecret string
+	backends    map[string]string // gRPC dial target -> backend name, for channelz
+}
+
+// newAdminMux builds the handler of the admin listener.
+// Admin routes are operational only and must not be exposed on the public port.
+func newAdminMux(deps adminDeps) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", deps.metrics.Registry.Handler())
+	mux.Handle("GET /log/level", logging.LevelHandler(deps.logLevel))
+	mux.Handle("/log/level", diag.RequireSecret(deps.debugSecret, logging.LevelHandler(deps.logLevel)))
+	mux.Handle("GET /history", history.Handler(deps.timeline))
+	if deps.cache != nil {
+		mux.Handle("GET /cache", cache.Handler(deps.cache))
+		mux.Handle("/cache", diag.RequireSecret(deps.debugSecret, cache.Handler(deps.cache)))
+	}
+	if deps.debugSecret != "" {
+		mux.Handle("/debug/", diag.Handler(deps.debugSecret, deps.backends))
+	}
+	return mux
+}
+
+// startAdminServer serves the admin routes on ADMIN_ADDR in the background.
+// An empty ADMIN_ADDR disables the admin listener.
+func startAdminServer(addr string, handler http.Handler) {
+	if addr == "" {
+		slog.Info("Admin listener disabled (ADMIN_ADDR is empty)")
+		return
+	}
+
+	slog.Info("Admin listener started", "addr", addr,
+		"metrics", "http://"+addr+"/metrics", "log_level", "http://"+addr+"/log/level",
+		"history", "http://"+addr+"/history")
+	go func() {
+		server := &http.Server{Addr: addr, Handler: handler}
+		fatal("Admin listener failed", "error", server.ListenAndServe())
+	}()
+}
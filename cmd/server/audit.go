@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/thatlq1812/service-3-gateway/internal/audit"
+	"github.com/thatlq1812/service-3-gateway/internal/rotate"
+)
+
+// auditActions maps the mutating routes to the audited backend operation
+var auditActions = map[string]audit.Action{
+	"POST /users":                  {Name: "CreateUser", Resource: "user"},
+	"POST /api/v1/users":           {Name: "CreateUser", Resource: "user"},
+	"PUT /api/v1/users/{id}":       {Name: "UpdateUser", Resource: "user"},
+	"PATCH /api/v1/users/{id}":     {Name: "UpdateUser", Resource: "user"},
+	"DELETE /api/v1/users/{id}":    {Name: "DeleteUser", Resource: "user"},
+	"POST /articles":               {Name: "CreateArticle", Resource: "article"},
+	"POST /api/v1/articles":        {Name: "CreateArticle", Resource: "article"},
+	"PUT /api/v1/articles/{id}":    {Name: "UpdateArticle", Resource: "article"},
+	"PATCH /api/v1/articles/{id}":  {Name: "UpdateArticle", Resource: "article"},
+	"DELETE /api/v1/articles/{id}": {Name: "DeleteArticle", Resource: "article"},
+}
+
+// loadAuditLogger opens the audit log from environment.
+// AUDIT_FILE=none disables auditing and returns nil.
+func loadAuditLogger() (*audit.Logger, error) {
+	path := getEnv("AUDIT_FILE", "audit.jsonl")
+	if path == "none" {
+		return nil, nil
+	}
+	return audit.Open(rotate.Config{
+		Path:       path,
+		MaxSize:    int64(getEnvInt("AUDIT_MAX_SIZE_MB", 100)) << 20,
+		MaxBackups: getEnvInt("AUDIT_MAX_FILES", 0),
+	})
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/backend"
+)
+
+// loadBackendConfig reads endpoints and load balancing of one backend from environment,
+// using the backend's variable prefix (e.g. USER_SERVICE):
+//
+//	<PREFIX>_ADDR           "host:port", "host1:port,host2:port", "dns:///host:port" or "registry:///name"
+//	<PREFIX>_LB_POLICY      round_robin (default) or least_request
+//	<PREFIX>_ROUTE_PREFER   registry metadata to prefer, e.g. "zone=hcm-1,version=1.3.0"
+//
+// Outlier ejection is shared by all backends:
+//
+//	BACKEND_OUTLIER_FAILURES             consecutive failures before ejection (default 5, 0 disables)
+//	BACKEND_OUTLIER_EJECTION_TIME        base ejection time (default 30s)
+//	BACKEND_OUTLIER_MAX_EJECTION_PERCENT max share of ejected endpoints (default 50)
+func loadBackendConfig(prefix, name, defaultAddr string, registry *backend.Registry) backend.Config {
+	outlier := backend.DefaultOutlierConfig()
+	outlier.ConsecutiveFailures = uint32(getEnvInt("BACKEND_OUTLIER_FAILURES", int(outlier.ConsecutiveFailures)))
+	outlier.EjectionTime = getEnvDuration("BACKEND_OUTLIER_EJECTION_TIME", outlier.EjectionTime)
+	outlier.MaxEjectionPercent = getEnvInt("BACKEND_OUTLIER_MAX_EJECTION_PERCENT", outlier.MaxEjectionPercent)
+
+	prefer := make(map[string]string)
+	for _, pair := range getEnvList(prefix + "_ROUTE_PREFER") {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			prefer[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+
+	return backend.Config{
+		Name:     name,
+		Address:  getEnv(prefix+"_ADDR", defaultAddr),
+		Policy:   getEnv(prefix+"_LB_POLICY", backend.PolicyRoundRobin),
+		Prefer:   prefer,
+		Outlier:  outlier,
+		Registry: registry,
+	}
+}
+
+// loadRegistry creates the file-watched service registry from environment, or nil if unset:
+//
+//	REGISTRY_PATH            JSON file, or directory of *.json files, listing endpoints per service
+//	REGISTRY_POLL_INTERVAL   how often the files are checked for changes (default 5s)
+func loadRegistry() *backend.Registry {
+	path := getEnv("REGISTRY_PATH", "")
+	if path == "" {
+		return nil
+	}
+	return backend.NewRegistry(path, getEnvDuration("REGISTRY_POLL_INTERVAL", 5*time.Second))
+}
+
+// loadBackendTransport reads transport security of one backend from environment,
+// using the backend's variable prefix (e.g. USER_SERVICE):
+//
+//	<PREFIX>_TLS_MODE          insecure (default), tls or mtls
+//	<PREFIX>_TLS_CA_FILE       CA bundle verifying the backend (system roots when empty)
+//	<PREFIX>_TLS_CERT_FILE     client certificate for mtls
+//	<PREFIX>_TLS_KEY_FILE      client key for mtls
+//	<PREFIX>_TLS_SERVER_NAME   name expected in the backend certificate (default: host of each endpoint)
+func loadBackendTransport(prefix string) (*backend.Transport, error) {
+	return backend.NewTransport(backend.TransportConfig{
+		Mode:       backend.TransportMode(getEnv(prefix+"_TLS_MODE", string(backend.TransportInsecure))),
+		CAFile:     getEnv(prefix+"_TLS_CA_FILE", ""),
+		CertFile:   getEnv(prefix+"_TLS_CERT_FILE", ""),
+		KeyFile:    getEnv(prefix+"_TLS_KEY_FILE", ""),
+		ServerName: getEnv(prefix+"_TLS_SERVER_NAME", ""),
+	})
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/cache"
+	"github.com/thatlq1812/service-3-gateway/internal/middleware"
+)
+
+// cacheRoutes are the cached read routes with their default TTL and invalidation tags.
+// Articles embed their author, so they also carry the "authors" tag.
+var cacheRoutes = map[string]middleware.CacheRoute{
+	"GET /api/v1/users/{id}":    {TTL: 30 * time.Second, Tags: []string{"user:{id}"}},
+	"GET /api/v1/users":         {TTL: 10 * time.Second, Tags: []string{"users"}},
+	"GET /api/v1/articles/{id}": {TTL: 30 * time.Second, Tags: []string{"article:{id}", "authors"}},
+	"GET /api/v1/articles":      {TTL: 10 * time.Second, Tags: []string{"articles", "authors"}},
+}
+
+// cacheInvalidations maps the write routes to the cache tags they make stale
+var cacheInvalidations = map[string][]string{
+	"POST /users":                  {"users"},
+	"POST /api/v1/users":           {"users"},
+	"PUT /api/v1/users/{id}":       {"user:{id}", "users", "authors"},
+	"PATCH /api/v1/users/{id}":     {"user:{id}", "users", "authors"},
+	"DELETE /api/v1/users/{id}":    {"user:{id}", "users", "authors"},
+	"POST /articles":               {"articles"},
+	"POST /api/v1/articles":        {"articles"},
+	"PUT /api/v1/articles/{id}":    {"article:{id}", "articles"},
+	"PATCH /api/v1/articles/{id}":  {"article:{id}", "articles"},
+	"DELETE /api/v1/articles/{id}": {"article:{id}", "articles"},
+}
+
+// loadCache builds the response cache config from environment.
+// CACHE_MAX_ENTRIES=0 disables the cache and returns a config without cache.
+func loadCache() (middleware.CacheConfig, error) {
+	cfg := middleware.CacheConfig{
+		Routes:        make(map[string]middleware.CacheRoute, len(cacheRoutes)),
+		Invalidations: cacheInvalidations,
+	}
+	for route, rule := range cacheRoutes {
+		cfg.Routes[route] = rule
+	}
+
+	// Per-route TTLs: /api/v1/users/{id}=1m,/api/v1/articles=0s (0 disables the route)
+	for _, item := range getEnvList("CACHE_ROUTE_TTLS") {
+		path, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return cfg, fmt.Errorf("invalid CACHE_ROUTE_TTLS entry %q, want <route>=<duration>", item)
+		}
+		route := "GET " + strings.TrimSpace(path)
+		rule, known := cfg.Routes[route]
+		if !known {
+			return cfg, fmt.Errorf("CACHE_ROUTE_TTLS: %s is not a cached route", route)
+		}
+		ttl, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil || ttl < 0 {
+			return cfg, fmt.Errorf("CACHE_ROUTE_TTLS: invalid TTL %q for %s", value, route)
+		}
+		rule.TTL = ttl
+		cfg.Routes[route] = rule
+	}
+
+	maxEntries := getEnvInt("CACHE_MAX_ENTRIES", 10000)
+	if maxEntries <= 0 {
+		return cfg, nil
+	}
+	cfg.Cache = cache.New(maxEntries, int64(getEnvInt("CACHE_MAX_SIZE_MB", 64))<<20)
+	return cfg, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/thatlq1812/service-3-gateway/internal/backend"
+	"github.com/thatlq1812/service-3-gateway/internal/health"
+)
+
+// criticalBackends returns the backends readiness depends on (HEALTH_CRITICAL_BACKENDS),
+// all of them by default
+func criticalBackends(names ...string) map[string]bool {
+	critical := make(map[string]bool)
+	list := getEnvList("HEALTH_CRITICAL_BACKENDS")
+	if len(list) == 0 {
+		list = names
+	}
+	for _, name := range list {
+		if strings.EqualFold(name, "none") {
+			return map[string]bool{}
+		}
+		critical[name] = true
+	}
+	return critical
+}
+
+// healthDetail is the status of one backend in the /health response
+type healthDetail struct {
+	Status          string                   `json:"status"` // Connectivity state, e.g. READY
+	Healthy         bool                     `json:"healthy"`
+	Critical        bool                     `json:"critical"`
+	Health          string                   `json:"health"` // grpc.health.v1 status, e.g. SERVING
+	LatencyMs       float64                  `json:"latency_ms"`
+	CheckedAt       time.Time                `json:"checked_at"`
+	Error           string                   `json:"error"`
+	BreakerState    string                   `json:"breaker_state"`
+	BreakerFailures uint32                   `json:"breaker_failures"`
+	Endpoints       []backend.EndpointStatus `json:"endpoints"`
+}
+
+// registerHealthRoutes adds the liveness, readiness and detailed health endpoints.
+// /health checks the backends again only when the last checks are older than refresh.
+func registerHealthRoutes(router *mux.Router, monitor *health.Monitor, refresh time.Duration) {
+	// Liveness: the process is up and serving HTTP, backends are not considered,
+	// so orchestrators never restart the gateway because a backend is down
+	router.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthJSON(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+	}).Methods("GET")
+
+	// Readiness: every critical backend passed its last health check
+	router.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		statuses := monitor.Status()
+		failing := []string{}
+		for _, st := range statuses {
+			if st.Critical && !st.Healthy {
+				failing = append(failing, st.Name)
+			}
+		}
+
+		if len(failing) > 0 {
+			writeHealthJSON(w, http.StatusServiceUnavailable, map[string]interface{}{
+				"status":  "not_ready",
+				"failing": failing,
+			})
+			return
+		}
+		writeHealthJSON(w, http.StatusOK, map[string]interface{}{"status": "ready"})
+	}).Methods("GET")
+
+	// Health detail: recent grpc.health.v1 check of every backend, with breaker and endpoint status.
+	// Only unhealthy critical backends make it return 503; partial degradation stays 200.
+	// The endpoint is public, so requests never trigger more than one check per refresh interval.
+	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		statuses := monitor.Refresh(r.Context(), refresh)
+
+		services := make(map[string]healthDetail, len(statuses))
+		for _, st := range statuses {
+			services[strings.ReplaceAll(st.Name, "-", "_")] = healthDetail{
+				Status:          st.Connectivity,
+				Healthy:         st.Healthy,
+				Critical:        st.Critical,
+				Health:          st.Health,
+				LatencyMs:       st.LatencyMs,
+				CheckedAt:       st.CheckedAt,
+				Error:           st.Error,
+				BreakerState:    st.BreakerState,
+				BreakerFailures: st.BreakerFailures,
+				Endpoints:       backend.Endpoints(st.Name).Status(),
+			}
+		}
+
+		statusCode := http.StatusOK
+		if !health.Ready(statuses) {
+			statusCode = http.StatusServiceUnavailable
+		}
+		writeHealthJSON(w, statusCode, map[string]interface{}{
+			"status":   health.Overall(statuses),
+			"services": services,
+		})
+	}).Methods("GET")
+}
+
+func writeHealthJSON(w http.ResponseWriter, statusCode int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/idempotency"
+	"github.com/thatlq1812/service-3-gateway/internal/middleware"
+)
+
+// idempotentRoutes are the create routes honouring the Idempotency-Key header
+var idempotentRoutes = map[string]bool{
+	"POST /users":           true,
+	"POST /api/v1/users":    true,
+	"POST /articles":        true,
+	"POST /api/v1/articles": true,
+}
+
+// loadIdempotency opens the idempotency store from environment.
+// IDEMPOTENCY_STORE=none disables Idempotency-Key handling and returns a config without store.
+func loadIdempotency() (middleware.IdempotencyConfig, error) {
+	cfg := middleware.IdempotencyConfig{
+		TTL:    getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		Routes: idempotentRoutes,
+	}
+	if cfg.TTL <= 0 {
+		return cfg, fmt.Errorf("IDEMPOTENCY_TTL must be positive")
+	}
+
+	switch kind := getEnv("IDEMPOTENCY_STORE", "memory"); kind {
+	case "none":
+	case "memory":
+		cfg.Store = idempotency.NewMemoryStore()
+	case "file":
+		store, err := idempotency.OpenFileStore(getEnv("IDEMPOTENCY_FILE", "idempotency.jsonl"))
+		if err != nil {
+			return cfg, err
+		}
+		cfg.Store = store
+	default:
+		return cfg, fmt.Errorf("unknown IDEMPOTENCY_STORE %q (memory, file or none)", kind)
+	}
+	return cfg, nil
+}
@@ -2,19 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
 	"google.golang.org/grpc"
-	"google.golang.org/grpc/credentials/insecure"
 
+	"github.com/thatlq1812/service-3-gateway/internal/accesslog"
+	"github.com/thatlq1812/service-3-gateway/internal/backend"
 	"github.com/thatlq1812/service-3-gateway/internal/circuit"
 	"github.com/thatlq1812/service-3-gateway/internal/handler"
+	"github.com/thatlq1812/service-3-gateway/internal/health"
+	"github.com/thatlq1812/service-3-gateway/internal/history"
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/logging"
+	"github.com/thatlq1812/service-3-gateway/internal/metrics"
 	"github.com/thatlq1812/service-3-gateway/internal/middleware"
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+	"github.com/thatlq1812/service-3-gateway/internal/tlsconfig"
+	"github.com/thatlq1812/service-3-gateway/internal/validation"
 
 	articlepb "github.com/thatlq1812/service-2-article/proto"
 
@@ -22,31 +36,30 @@ import (
 )
 
 // connectWithRetry attempts to establish gRPC connection with exponential backoff
-func connectWithRetry(address string, serviceName string, maxRetries int) (*grpc.ClientConn, error) {
+func connectWithRetry(address string, serviceName string, maxRetries int, opts ...grpc.DialOption) (*grpc.ClientConn, error) {
 	backoff := 1 * time.Second
 	maxBackoff := 30 * time.Second
 
 	for i := 0; i < maxRetries; i++ {
-		log.Printf("[%s] Connection attempt %d/%d to %s", serviceName, i+1, maxRetries, address)
+		slog.Info("Connecting to backend", "backend", serviceName, "attempt", i+1, "max_attempts", maxRetries, "address", address)
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		conn, err := grpc.DialContext(
 			ctx,
 			address,
-			grpc.WithTransportCredentials(insecure.NewCredentials()),
-			grpc.WithBlock(), // Block until connected or timeout
+			append(opts, grpc.WithBlock())..., // Block until connected or timeout
 		)
 		cancel()
 
 		if err == nil {
-			log.Printf("[%s] Successfully connected to %s", serviceName, address)
+			slog.Info("Connected to backend", "backend", serviceName, "address", address)
 			return conn, nil
 		}
 
-		log.Printf("[%s] Connection attempt %d failed: %v", serviceName, i+1, err)
+		slog.Warn("Backend connection attempt failed", "backend", serviceName, "attempt", i+1, "error", err)
 
 		if i < maxRetries-1 {
-			log.Printf("[%s] Retrying in %v...", serviceName, backoff)
+			slog.Info("Retrying backend connection", "backend", serviceName, "backoff", backoff.String())
 			time.Sleep(backoff)
 
 			// Exponential backoff
@@ -61,34 +74,110 @@ func connectWithRetry(address string, serviceName string, maxRetries int) (*grpc
 }
 
 func main() {
+	// Structured logging (JSON by default), the level can be changed at runtime on the admin listener
+	logLevel := new(slog.LevelVar)
+	level, err := logging.ParseLevel(getEnv("LOG_LEVEL", "info"))
+	if err != nil {
+		fatal("Invalid LOG_LEVEL", "error", err)
+	}
+	logLevel.Set(level)
+	logger, err := logging.New(os.Stdout, getEnv("LOG_FORMAT", logging.FormatJSON), logLevel)
+	if err != nil {
+		fatal("Invalid LOG_FORMAT", "error", err)
+	}
+	// Also routes the standard log package (used by gRPC and net/http) through the redacting handler
+	slog.SetDefault(logger)
+
 	// Get service addresses from environment
-	userServiceAddr := getEnv("USER_SERVICE_ADDR", "localhost:50051")
-	articleServiceAddr := getEnv("ARTICLE_SERVICE_ADDR", "localhost:50052")
+	registry := loadRegistry()
+	userBackend := loadBackendConfig("USER_SERVICE", "user-service", "localhost:50051", registry)
+	articleBackend := loadBackendConfig("ARTICLE_SERVICE", "article-service", "localhost:50052", registry)
 	gatewayPort := getEnv("GATEWAY_PORT", "8080")
 
-	log.Printf("Starting API Gateway...")
-	log.Printf("User Service: %s", userServiceAddr)
-	log.Printf("Article Service: %s", articleServiceAddr)
+	slog.Info("Starting API Gateway")
+	if registry != nil {
+		slog.Info("Service registry enabled", "path", getEnv("REGISTRY_PATH", ""))
+	}
+	slog.Info("Backend configured", "backend", userBackend.Name, "target", userBackend.Target(), "lb", userBackend.Policy)
+	slog.Info("Backend configured", "backend", articleBackend.Name, "target", articleBackend.Target(), "lb", articleBackend.Policy)
+
+	// Load transport security for each backend
+	userTransport, err := loadBackendTransport("USER_SERVICE")
+	if err != nil {
+		fatal("Invalid User Service transport config", "error", err)
+	}
+	articleTransport, err := loadBackendTransport("ARTICLE_SERVICE")
+	if err != nil {
+		fatal("Invalid Article Service transport config", "error", err)
+	}
+	slog.Info("Backend transport", "backend", userBackend.Name, "transport", userTransport.String())
+	slog.Info("Backend transport", "backend", articleBackend.Name, "transport", articleTransport.String())
+	if userTransport.Mode() == backend.TransportInsecure {
+		slog.Warn("User Service transport is plaintext, passwords from CreateUser/Login are not encrypted")
+	}
+
+	// Metrics are served on the admin listener, not on the public port
+	gatewayMetrics := metrics.NewGateway()
+
+	// Distributed tracing (W3C trace context), disabled unless TRACING_EXPORTER is set
+	tracer, err := loadTracer()
+	if err != nil {
+		fatal("Invalid tracing config", "error", err)
+	}
+	if tracer != nil {
+		slog.Info("Tracing enabled", "exporter", getEnv("TRACING_EXPORTER", ""))
+		defer tracer.Shutdown(context.Background())
+	}
+
+	// Interceptors of every backend call: request ID and trace propagation, metrics, logging
+	backendInterceptors := func(name string) grpc.DialOption {
+		interceptors := []grpc.UnaryClientInterceptor{backend.RequestIDInterceptor()}
+		if tracer != nil {
+			interceptors = append(interceptors, tracer.UnaryClientInterceptor(name))
+		}
+		interceptors = append(interceptors,
+			gatewayMetrics.UnaryClientInterceptor(),
+			logging.UnaryClientInterceptor(logger, name),
+		)
+		return grpc.WithChainUnaryInterceptor(interceptors...)
+	}
+
+	// Reload backend client certificates and CAs on change (only new connections use them)
+	tlsReloadInterval := getEnvDuration("TLS_RELOAD_INTERVAL", 30*time.Second)
+	go tlsconfig.Watch(context.Background(), tlsReloadInterval, logTLSReload,
+		append(userTransport.Reloaders(), articleTransport.Reloaders()...)...)
+
+	// Resolver and load balancing options for each backend
+	userDialOpts, err := userBackend.DialOptions()
+	if err != nil {
+		fatal("Invalid User Service config", "error", err)
+	}
+	articleDialOpts, err := articleBackend.DialOptions()
+	if err != nil {
+		fatal("Invalid Article Service config", "error", err)
+	}
 
 	// Connect to User Service (gRPC) with retry logic
-	log.Printf("Connecting to User Service...")
-	userConn, err := connectWithRetry(userServiceAddr, "User Service", 5)
+	userConn, err := connectWithRetry(userBackend.Target(), userBackend.Name, 5,
+		append(userDialOpts,
+			grpc.WithTransportCredentials(userTransport.Credentials()),
+			backendInterceptors(userBackend.Name))...)
 	if err != nil {
-		log.Fatalf("Failed to connect to User Service after retries: %v", err)
+		fatal("Failed to connect to User Service after retries", "error", err)
 	}
 	defer userConn.Close()
 	userClient := userpb.NewUserServiceClient(userConn)
-	log.Printf("✓ Connected to User Service")
 
 	// Connect to Article Service (gRPC) with retry logic
-	log.Printf("Connecting to Article Service...")
-	articleConn, err := connectWithRetry(articleServiceAddr, "Article Service", 5)
+	articleConn, err := connectWithRetry(articleBackend.Target(), articleBackend.Name, 5,
+		append(articleDialOpts,
+			grpc.WithTransportCredentials(articleTransport.Credentials()),
+			backendInterceptors(articleBackend.Name))...)
 	if err != nil {
-		log.Fatalf("Failed to connect to Article Service after retries: %v", err)
+		fatal("Failed to connect to Article Service after retries", "error", err)
 	}
 	defer articleConn.Close()
 	articleClient := articlepb.NewArticleServiceClient(articleConn)
-	log.Printf("✓ Connected to Article Service")
 
 	// Initialize circuit breakers for each service
 	// maxFailures: 5 consecutive failures trigger circuit open
@@ -96,9 +185,27 @@ func main() {
 	userCircuit := circuit.NewBreaker(5, 30*time.Second)
 	articleCircuit := circuit.NewBreaker(5, 30*time.Second)
 
-	log.Printf("Circuit Breakers initialized")
-	log.Printf("- User Service: max_failures=5, reset_timeout=30s")
-	log.Printf("- Article Service: max_failures=5, reset_timeout=30s")
+	gatewayMetrics.RegisterBreaker(userBackend.Name, userCircuit)
+	gatewayMetrics.RegisterBreaker(articleBackend.Name, articleCircuit)
+
+	slog.Info("Circuit breakers initialized", "max_failures", 5, "reset_timeout", "30s")
+
+	// API code -> HTTP status table of backend responses, extended per deployment for new codes
+	if path := getEnv("RESPONSE_CODES_FILE", ""); path != "" {
+		codes, err := response.LoadCodes(path)
+		if err != nil {
+			fatal("Failed to load response codes", "error", err)
+		}
+		response.SetCodes(codes)
+		slog.Info("Response codes loaded", "file", path, "codes", len(codes))
+	}
+
+	// Field rules and password policy of request payloads, per deployment
+	validator, err := loadValidation()
+	if err != nil {
+		fatal("Failed to load validation rules", "error", err)
+	}
+	validation.SetDefault(validator)
 
 	// Initialize handlers with circuit breakers
 	userHandler := handler.NewUserHandlerWithCircuit(userClient, userCircuit)
@@ -107,106 +214,301 @@ func main() {
 	// Create HTTP Router (receive REST request)
 	router := mux.NewRouter()
 
-	// Define REST endpoints
-	router.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
-	router.HandleFunc("/articles", articleHandler.CreateArticle).Methods("POST")
+	// Record request metrics first so the latency includes every other middleware
+	router.Use(middleware.MetricsMiddleware(gatewayMetrics))
+
+	// Accept or generate X-Request-ID before anything can log or fail
+	router.Use(middleware.RequestIDMiddleware)
+
+	// Server span per request, continuing an incoming traceparent
+	if tracer != nil {
+		router.Use(middleware.TracingMiddleware(tracer))
+	}
+
+	// Language of gateway messages, negotiated from Accept-Language
+	catalog, err := loadCatalog()
+	if err != nil {
+		fatal("Failed to load message catalog", "error", err)
+	}
+	i18n.SetDefault(catalog)
+	router.Use(middleware.LocaleMiddleware(catalog))
+
+	// RFC 7807 problem+json errors, wrapping every middleware that can fail a request
+	problemConfig, err := loadProblemConfig()
+	if err != nil {
+		fatal("Invalid error format", "error", err)
+	}
+	router.Use(middleware.ProblemMiddleware(problemConfig))
 
 	// Add global timeout middleware (5 seconds per request)
-	router.Use(middleware.TimeoutMiddleware(5 * time.Second))
+	router.Use(middleware.TimeoutMiddlewareWithHook(5*time.Second, func(r *http.Request) {
+		gatewayMetrics.RequestTimedOut(middleware.RouteTemplate(r), r.Method)
+	}))
 
-	// Add logging middleware
-	router.Use(loggingMiddleware)
+	// Add structured access logging (secrets in query strings are masked)
+	router.Use(middleware.LoggingMiddleware(logger))
+
+	// Access log in Combined Log Format or JSON, with rotation (disabled unless ACCESS_LOG_FILE is set)
+	accessLog, accessLogFile, err := loadAccessLog()
+	if err != nil {
+		fatal("Invalid access log config", "error", err)
+	}
+	if accessLogFile != nil {
+		defer accessLogFile.Close()
+	}
+	if accessLog != nil {
+		slog.Info("Access log enabled", "path", getEnv("ACCESS_LOG_FILE", ""),
+			"format", getEnv("ACCESS_LOG_FORMAT", accesslog.FormatCombined))
+		router.Use(middleware.AccessLogMiddleware(accessLog))
+	}
+
+	// Per-backend Server-Timing header, for every caller or for admin principals only
+	serverTiming, err := loadServerTiming()
+	if err != nil {
+		fatal("Invalid Server-Timing config", "error", err)
+	}
+	router.Use(middleware.ServerTimingMiddleware(serverTiming))
 
 	// Add CORS middleware for development
 	router.Use(corsMiddleware)
 
+	// Map verified client certificates (mTLS) to a principal for internal callers
+	router.Use(middleware.ClientCertMiddleware(loadClientPrincipals()))
+
+	// Add security response headers (defaults can be tuned per deployment)
+	router.Use(middleware.SecurityHeadersMiddleware(loadSecurityHeaders()))
+
+	// LRU cache of user and article reads, invalidated by the writes handled here
+	cacheConfig, err := loadCache()
+	if err != nil {
+		fatal("Invalid response cache config", "error", err)
+	}
+	if cacheConfig.Cache != nil {
+		gatewayMetrics.RegisterCache(cacheConfig.Cache)
+		slog.Info("Response cache enabled", "max_entries", cacheConfig.Cache.Stats().MaxEntries)
+	} else {
+		slog.Info("Response cache disabled (CACHE_MAX_ENTRIES=0)")
+	}
+	router.Use(middleware.ResponseCacheMiddleware(cacheConfig))
+
+	// Replay create responses for retries with the same Idempotency-Key (outside the audit
+	// log, so replays are not recorded as new mutations)
+	idempotencyConfig, err := loadIdempotency()
+	if err != nil {
+		fatal("Invalid idempotency config", "error", err)
+	}
+	if idempotencyConfig.Store != nil {
+		defer idempotencyConfig.Store.Close()
+		slog.Info("Idempotency keys enabled", "store", getEnv("IDEMPOTENCY_STORE", "memory"),
+			"ttl", idempotencyConfig.TTL.String())
+	}
+	router.Use(middleware.IdempotencyMiddleware(idempotencyConfig))
+
+	// Hash-chained audit log of user and article mutations (after the principal is known)
+	auditLogger, err := loadAuditLogger()
+	if err != nil {
+		fatal("Failed to open audit log", "error", err)
+	}
+	if auditLogger != nil {
+		defer auditLogger.Close()
+		slog.Info("Audit log enabled", "path", getEnv("AUDIT_FILE", "audit.jsonl"))
+		router.Use(middleware.AuditMiddleware(auditLogger, auditActions))
+	} else {
+		slog.Warn("Audit log disabled (AUDIT_FILE=none)")
+	}
+
+	// Define REST endpoints
+	registerAPIRoutes(router, userHandler, articleHandler)
+
+	// Liveness, readiness and health detail using grpc.health.v1 on each backend
+	critical := criticalBackends(userBackend.Name, articleBackend.Name)
+	healthMonitor := health.NewMonitor(getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+		health.Backend{
+			Name:     userBackend.Name,
+			Conn:     userConn,
+			Service:  getEnv("USER_SERVICE_HEALTH_SERVICE", ""),
+			Breaker:  userCircuit,
+			Critical: critical[userBackend.Name],
+		},
+		health.Backend{
+			Name:     articleBackend.Name,
+			Conn:     articleConn,
+			Service:  getEnv("ARTICLE_SERVICE_HEALTH_SERVICE", ""),
+			Breaker:  articleCircuit,
+			Critical: critical[articleBackend.Name],
+		},
+	)
+
+	// Availability timeline: connection states, breaker transitions and health results
+	timeline, err := history.New(getEnvInt("HISTORY_SIZE", 1000), getEnv("HISTORY_FILE", ""))
+	if err != nil {
+		fatal("Failed to open history file", "error", err)
+	}
+	defer timeline.Close()
+	for _, b := range []struct {
+		name    string
+		conn    *grpc.ClientConn
+		breaker *circuit.Breaker
+	}{
+		{userBackend.Name, userConn, userCircuit},
+		{articleBackend.Name, articleConn, articleCircuit},
+	} {
+		name := b.name
+		go timeline.WatchConnectivity(context.Background(), name, b.conn)
+		timeline.Record(name, history.KindBreaker, b.breaker.GetStateString(), "")
+		b.breaker.OnStateChange(func(from, to circuit.State) {
+			timeline.Record(name, history.KindBreaker, to.String(), "")
+			slog.Warn("Circuit breaker state changed", "backend", name, "from", from.String(), "to", to.String())
+		})
+	}
+	healthMonitor.OnResult(func(backend string, result health.Result) {
+		timeline.Record(backend, history.KindHealth, result.Status, result.Error)
+	})
+
+	go healthMonitor.Run(context.Background(), getEnvDuration("HEALTH_CHECK_INTERVAL", 10*time.Second))
+	registerHealthRoutes(router, healthMonitor, getEnvDuration("HEALTH_REFRESH_INTERVAL", 5*time.Second))
+
+	// OpenAPI document of every route above, fails when a route is not documented
+	apiDoc, err := registerOpenAPIRoutes(router)
+	if err != nil {
+		fatal("OpenAPI document out of date with the routes", "error", err)
+	}
+
+	// Validate parameters and JSON bodies against the schema before calling any backend.
+	// Added last so it runs innermost, after the request is logged, traced and audited.
+	requestValidator, err := loadRequestValidator(apiDoc)
+	if err != nil {
+		fatal("Failed to load OpenAPI schema file", "error", err)
+	}
+	if requestValidator != nil {
+		slog.Info("Request validation enabled", "schema", getEnv("OPENAPI_SCHEMA_FILE", "generated"))
+		router.Use(middleware.RequestValidationMiddleware(requestValidator, int64(getEnvInt("REQUEST_MAX_BODY_BYTES", 1<<20))))
+	}
+
+	// Admin listener (metrics), bound to localhost unless configured otherwise
+	adminAddr, ok := os.LookupEnv("ADMIN_ADDR")
+	if !ok {
+		adminAddr = "127.0.0.1:9090"
+	}
+	debugSecret := getEnv("ADMIN_SECRET", "")
+	if debugSecret == "" {
+		slog.Info("Debug endpoints disabled (ADMIN_SECRET is empty)")
+	} else if len(debugSecret) < 16 {
+		slog.Warn("ADMIN_SECRET is shorter than 16 characters")
+	}
+	startAdminServer(adminAddr, newAdminMux(adminDeps{
+		metrics:     gatewayMetrics,
+		logLevel:    logLevel,
+		timeline:    timeline,
+		cache:       cacheConfig.Cache,
+		debugSecret: debugSecret,
+		backends: map[string]string{
+			userBackend.Target():    userBackend.Name,
+			articleBackend.Target(): articleBackend.Name,
+		},
+	}))
+
+	// Start server
+	addr := ":" + gatewayPort
+	server := &http.Server{Addr: addr, Handler: router}
+
+	serverTLS, err := loadServerTLS()
+	if err != nil {
+		fatal("Failed to load TLS configuration", "error", err)
+	}
+
+	serve := server.ListenAndServe
+	if serverTLS == nil {
+		slog.Info("API Gateway listening", "addr", addr, "tls", false,
+			"health", "http://localhost"+addr+"/health", "api", "http://localhost"+addr+"/api/v1")
+	} else {
+		server.TLSConfig, err = serverTLS.TLSConfig()
+		if err != nil {
+			fatal("Failed to build TLS configuration", "error", err)
+		}
+
+		// Reload certificates and client CAs when their files change, without restart
+		go tlsconfig.Watch(context.Background(), tlsReloadInterval, logTLSReload, serverTLS.Reloaders()...)
+
+		slog.Info("API Gateway listening", "addr", addr, "tls", true,
+			"certificates", len(serverTLS.Certificates), "client_auth", serverTLS.ClientCAs != nil,
+			"health", "https://localhost"+addr+"/health", "api", "https://localhost"+addr+"/api/v1")
+		// Certificates come from TLSConfig.GetCertificate
+		serve = func() error { return server.ListenAndServeTLS("", "") }
+	}
+
+	// Stop gracefully on SIGINT/SIGTERM: finish in-flight requests, then run the
+	// deferred cleanup (backend connections, trace export)
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		<-ctx.Done()
+
+		slog.Info("Shutting down API Gateway")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), getEnvDuration("SHUTDOWN_TIMEOUT", 10*time.Second))
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			slog.Error("Graceful shutdown failed", "error", err)
+		}
+	}()
+
+	if err := serve(); !errors.Is(err, http.ErrServerClosed) {
+		fatal("Server stopped", "error", err)
+	}
+	<-stopped
+	slog.Info("API Gateway stopped")
+}
+
+// registerAPIRoutes adds the legacy and /api/v1 user, auth and article routes
+func registerAPIRoutes(router *mux.Router, userHandler *handler.UserHandler, articleHandler *handler.ArticleHandler) {
+	// Legacy routes
+	router.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
+	router.HandleFunc("/articles", articleHandler.CreateArticle).Methods("POST")
+
 	// API v1 routes
 	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(middleware.CacheControlMiddleware(getEnv("API_CACHE_CONTROL", "no-store")))
 
 	// User routes
 	api.HandleFunc("/users", userHandler.CreateUser).Methods("POST")
 	api.HandleFunc("/users", userHandler.ListUsers).Methods("GET")
 	api.HandleFunc("/users/{id}", userHandler.GetUser).Methods("GET")
 	api.HandleFunc("/users/{id}", userHandler.UpdateUser).Methods("PUT")
+	api.HandleFunc("/users/{id}", userHandler.PatchUser).Methods("PATCH")
 	api.HandleFunc("/users/{id}", userHandler.DeleteUser).Methods("DELETE")
 
-	// Auth routes
-	api.HandleFunc("/auth/login", userHandler.Login).Methods("POST")
-	api.HandleFunc("/auth/refresh", userHandler.RefreshToken).Methods("POST")
-	api.HandleFunc("/auth/validate", userHandler.ValidateToken).Methods("POST")
-	api.HandleFunc("/auth/logout", userHandler.Logout).Methods("POST")
+	// Auth routes (tokens must never be cached, whatever the config says)
+	auth := api.PathPrefix("/auth").Subrouter()
+	auth.Use(middleware.NoStoreMiddleware)
+	auth.HandleFunc("/login", userHandler.Login).Methods("POST")
+	auth.HandleFunc("/refresh", userHandler.RefreshToken).Methods("POST")
+	auth.HandleFunc("/validate", userHandler.ValidateToken).Methods("POST")
+	auth.HandleFunc("/logout", userHandler.Logout).Methods("POST")
 
 	// Article routes
 	api.HandleFunc("/articles", articleHandler.CreateArticle).Methods("POST")
 	api.HandleFunc("/articles", articleHandler.ListArticles).Methods("GET")
 	api.HandleFunc("/articles/{id}", articleHandler.GetArticle).Methods("GET")
 	api.HandleFunc("/articles/{id}", articleHandler.UpdateArticle).Methods("PUT")
+	api.HandleFunc("/articles/{id}", articleHandler.PatchArticle).Methods("PATCH")
 	api.HandleFunc("/articles/{id}", articleHandler.DeleteArticle).Methods("DELETE")
-
-	// Health check with backend service status
-	router.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-
-		// Check User Service connection
-		userState := userConn.GetState().String()
-		userHealthy := userState == "READY"
-
-		// Check Article Service connection
-		articleState := articleConn.GetState().String()
-		articleHealthy := articleState == "READY"
-
-		// Overall health
-		overallHealthy := userHealthy && articleHealthy
-		statusCode := http.StatusOK
-		status := "healthy"
-
-		if !overallHealthy {
-			statusCode = http.StatusServiceUnavailable
-			status = "degraded"
-		}
-
-		response := fmt.Sprintf(`{
-	"status": "%s",
-	"services": {
-		"user_service": {
-			"status": "%s",
-			"healthy": %v
-		},
-		"article_service": {
-			"status": "%s",
-			"healthy": %v
-		}
-	}
-}`, status, userState, userHealthy, articleState, articleHealthy)
-
-		w.WriteHeader(statusCode)
-		w.Write([]byte(response))
-	}).Methods("GET")
-
-	// Start server
-	addr := ":" + gatewayPort
-	log.Printf("API Gateway listening on %s", addr)
-	log.Printf("Health check: http://localhost%s/health", addr)
-	log.Printf("API Base URL: http://localhost%s/api/v1", addr)
-	log.Fatal(http.ListenAndServe(addr, router))
 }
 
-// loggingMiddleware logs all incoming requests
-func loggingMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		log.Printf("[%s] %s %s", r.Method, r.RequestURI, r.RemoteAddr)
-		next.ServeHTTP(w, r)
-		log.Printf("[%s] %s completed in %v", r.Method, r.RequestURI, time.Since(start))
-	})
+// fatal logs an error and exits, like log.Fatal for the structured logger
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
 
 // corsMiddleware adds CORS headers for development
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, If-Match, If-None-Match, Idempotency-Key")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Server-Timing, Retry-After, ETag, Idempotent-Replayed, Age, X-Cache")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
@@ -217,6 +519,92 @@ func corsMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// loadSecurityHeaders builds the global security headers config from environment
+func loadSecurityHeaders() middleware.SecurityHeadersConfig {
+	cfg := middleware.DefaultSecurityHeaders()
+	cfg.HSTSMaxAge = getEnvDuration("SECURITY_HSTS_MAX_AGE", cfg.HSTSMaxAge)
+	cfg.HSTSIncludeSubdomains = getEnvBool("SECURITY_HSTS_INCLUDE_SUBDOMAINS", cfg.HSTSIncludeSubdomains)
+	// Set but empty disables the header
+	cfg.ContentSecurityPolicy = getEnvOrEmpty("SECURITY_CSP", cfg.ContentSecurityPolicy)
+	cfg.ReferrerPolicy = getEnvOrEmpty("SECURITY_REFERRER_POLICY", cfg.ReferrerPolicy)
+	cfg.FrameOptions = getEnvOrEmpty("SECURITY_FRAME_OPTIONS", cfg.FrameOptions)
+	// Health and other non-API routes may be revalidated, API routes override below
+	cfg.CacheControl = getEnvOrEmpty("SECURITY_CACHE_CONTROL", cfg.CacheControl)
+	return cfg
+}
+
+// loadServerTiming builds the Server-Timing config from environment
+func loadServerTiming() (middleware.ServerTimingConfig, error) {
+	cfg := middleware.ServerTimingConfig{
+		Mode:   strings.ToLower(getEnv("SERVER_TIMING", middleware.ServerTimingOff)),
+		Admins: make(map[string]bool),
+	}
+	switch cfg.Mode {
+	case middleware.ServerTimingOff, middleware.ServerTimingAll, middleware.ServerTimingAdmin:
+	default:
+		return cfg, fmt.Errorf("unknown SERVER_TIMING %q (want off, all or admin)", cfg.Mode)
+	}
+	for _, principal := range getEnvList("SERVER_TIMING_ADMINS") {
+		cfg.Admins[principal] = true
+	}
+	if cfg.Mode == middleware.ServerTimingAdmin && len(cfg.Admins) == 0 {
+		slog.Warn("SERVER_TIMING=admin but SERVER_TIMING_ADMINS is empty, the header is never sent")
+	}
+	return cfg, nil
+}
+
+// loadCatalog builds the message catalog: the embedded locales plus LOCALES_DIR,
+// falling back to DEFAULT_LANGUAGE
+func loadCatalog() (*i18n.Catalog, error) {
+	var dirs []string
+	if dir := getEnv("LOCALES_DIR", ""); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	catalog, err := i18n.New(getEnv("DEFAULT_LANGUAGE", i18n.DefaultLanguage), dirs...)
+	if err != nil {
+		return nil, err
+	}
+	for _, lang := range catalog.Languages() {
+		if missing := catalog.Missing(lang); len(missing) > 0 {
+			slog.Warn("Locale is missing messages, the fallback language is used for them", "language", lang, "keys", missing)
+		}
+	}
+	slog.Info("Message catalog loaded", "languages", catalog.Languages())
+	return catalog, nil
+}
+
+// loadProblemConfig builds the error rendering config from environment
+func loadProblemConfig() (middleware.ProblemConfig, error) {
+	cfg := middleware.ProblemConfig{
+		Format:   strings.ToLower(getEnv("ERROR_FORMAT", middleware.ErrorFormatNegotiate)),
+		TypeBase: getEnv("PROBLEM_TYPE_BASE", ""),
+	}
+	switch cfg.Format {
+	case middleware.ErrorFormatEnvelope, middleware.ErrorFormatNegotiate, middleware.ErrorFormatProblem:
+	default:
+		return cfg, fmt.Errorf("unknown ERROR_FORMAT %q (want envelope, negotiate or problem)", cfg.Format)
+	}
+	return cfg, nil
+}
+
+// loadValidation builds the payload validator, with the rules file VALIDATION_RULES_FILE if set
+func loadValidation() (*validation.Validator, error) {
+	cfg := validation.DefaultConfig()
+	if path := getEnv("VALIDATION_RULES_FILE", ""); path != "" {
+		var err error
+		if cfg, err = validation.LoadConfig(path); err != nil {
+			return nil, err
+		}
+		slog.Info("Validation rules loaded", "file", path, "overrides", len(cfg.Rules))
+	}
+	v, err := validation.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return v, v.Check(handler.CreateUserRequest{}, handler.UpdateUserRequest{}, handler.PatchUserRequest{},
+		handler.CreateArticleRequest{}, handler.UpdateArticleRequest{})
+}
+
 // getEnv gets environment variable with fallback
 func getEnv(key, fallback string) string {
 	if value := os.Getenv(key); value != "" {
@@ -224,3 +612,49 @@ func getEnv(key, fallback string) string {
 	}
 	return fallback
 }
+
+// getEnvOrEmpty gets environment variable with fallback, keeping a value set to ""
+func getEnvOrEmpty(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList gets comma-separated environment variable as a list (empty entries removed)
+func getEnvList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvInt gets integer environment variable with fallback
+func getEnvInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvBool gets boolean environment variable with fallback
+func getEnvBool(key string, fallback bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+// getEnvDuration gets duration environment variable (e.g. "30s") with fallback
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"github.com/gorilla/mux"
+
+	"github.com/thatlq1812/service-3-gateway/internal/handler"
+	"github.com/thatlq1812/service-3-gateway/internal/openapi"
+)
+
+// idParam is the integer {id} path parameter of user and article routes
+var idParam = openapi.Parameter{Name: "id", In: "path", Required: true,
+	Schema: &openapi.Schema{Type: "integer", Format: "int32", Minimum: openapi.Bound(1)}}
+
+// ifMatchParam and ifNoneMatchParam are the conditional request headers of single user and article routes
+var ifMatchParam = openapi.Parameter{Name: "If-Match", In: "header",
+	Description: "ETag of the version being modified; 412 with code 009 if the entity changed since",
+	Schema:      &openapi.Schema{Type: "string"}}
+
+var ifNoneMatchParam = openapi.Parameter{Name: "If-None-Match", In: "header",
+	Description: "ETag of a cached version; 304 without body if it is still current",
+	Schema:      &openapi.Schema{Type: "string"}}
+
+// idempotencyKeyParam is the Idempotency-Key header of create routes
+var idempotencyKeyParam = openapi.Parameter{Name: "Idempotency-Key", In: "header",
+	Description: "Unique key of the request; a retry with the same key and body replays the first response (Idempotent-Replayed: true); 409 while it is in flight, 422 if the body differs. 1 to 255 printable ASCII characters",
+	Schema:      &openapi.Schema{Type: "string"}}
+
+// pageParams are the pagination query parameters of list routes
+var pageParams = []openapi.Parameter{
+	{Name: "page", In: "query", Description: "Page number, from 1",
+		Schema: &openapi.Schema{Type: "integer", Format: "int32", Minimum: openapi.Bound(1), Default: 1}},
+	{Name: "page_size", In: "query", Description: "Items per page",
+		Schema: &openapi.Schema{Type: "integer", Format: "int32", Minimum: openapi.Bound(1), Maximum: openapi.Bound(100), Default: 10}},
+}
+
+// apiRoutes documents every route registered in main; the gateway refuses to start
+// when a route is added without an entry here (see openapi.Generate)
+var apiRoutes = []openapi.Route{
+	// Health
+	{Method: "GET", Path: "/livez", OperationID: "livez", Tags: []string{"health"}, Raw: true,
+		Summary: "Liveness: the gateway process is serving HTTP",
+		Response: struct {
+			Status string `json:"status"`
+		}{}},
+	{Method: "GET", Path: "/readyz", OperationID: "readyz", Tags: []string{"health"}, Raw: true,
+		Summary:     "Readiness: every critical backend is healthy",
+		Description: "Returns 503 with the failing critical backends otherwise.",
+		Response: struct {
+			Status  string   `json:"status"`
+			Failing []string `json:"failing,omitempty"`
+		}{}},
+	{Method: "GET", Path: "/health", OperationID: "health", Tags: []string{"health"}, Raw: true,
+		Summary:     "Health detail of every backend (grpc.health.v1), breaker and endpoints",
+		Description: "status is healthy, degraded or unhealthy. Returns 503 only when a critical backend is unhealthy.",
+		Response: struct {
+			Status   string                  `json:"status"`
+			Services map[string]healthDetail `json:"services"` // Keyed by backend name, e.g. user_service
+		}{}},
+
+	// Legacy routes, same as their /api/v1 equivalent
+	{Method: "POST", Path: "/users", OperationID: "createUserLegacy", Tags: []string{"users"},
+		Summary: "Create a user (legacy path of POST /api/v1/users)",
+		Params:  []openapi.Parameter{idempotencyKeyParam}, Request: handler.CreateUserRequest{}, Response: handler.UserData{}},
+	{Method: "POST", Path: "/articles", OperationID: "createArticleLegacy", Tags: []string{"articles"},
+		Summary: "Create an article (legacy path of POST /api/v1/articles)", Auth: true,
+		Params: []openapi.Parameter{idempotencyKeyParam}, Request: handler.CreateArticleRequest{}, Response: handler.ArticleData{}},
+
+	// Users
+	{Method: "POST", Path: "/api/v1/users", OperationID: "createUser", Tags: []string{"users"},
+		Summary: "Create a user", Params: []openapi.Parameter{idempotencyKeyParam}, Request: handler.CreateUserRequest{}, Response: handler.UserData{}},
+	{Method: "GET", Path: "/api/v1/users", OperationID: "listUsers", Tags: []string{"users"},
+		Summary: "List users", Params: pageParams, Response: handler.UserData{}, List: true},
+	{Method: "GET", Path: "/api/v1/users/{id}", OperationID: "getUser", Tags: []string{"users"},
+		Summary: "Get a user", Params: []openapi.Parameter{idParam, ifNoneMatchParam}, Response: handler.UserData{}},
+	{Method: "PUT", Path: "/api/v1/users/{id}", OperationID: "updateUser", Tags: []string{"users"},
+		Summary: "Update a user", Description: "Empty fields are left unchanged.",
+		Params: []openapi.Parameter{idParam, ifMatchParam}, Request: handler.UpdateUserRequest{}, Response: handler.UserData{}},
+	{Method: "PATCH", Path: "/api/v1/users/{id}", OperationID: "patchUser", Tags: []string{"users"},
+		Summary:     "Partially update a user",
+		Description: "RFC 7396 JSON merge patch: absent members are unchanged, `null` or `\"\"` clears the name. Email and password cannot be removed.",
+		Params:      []openapi.Parameter{idParam, ifMatchParam}, Request: handler.PatchUserRequest{}, Response: handler.UserData{}},
+	{Method: "DELETE", Path: "/api/v1/users/{id}", OperationID: "deleteUser", Tags: []string{"users"},
+		Summary: "Delete a user", Params: []openapi.Parameter{idParam, ifMatchParam}, Response: handler.SuccessData{}},
+
+	// Auth
+	{Method: "POST", Path: "/api/v1/auth/login", OperationID: "login", Tags: []string{"auth"},
+		Summary: "Log in with email and password", Request: handler.LoginRequest{}, Response: handler.TokenData{}},
+	{Method: "POST", Path: "/api/v1/auth/refresh", OperationID: "refreshToken", Tags: []string{"auth"},
+		Summary: "Exchange a refresh token for a new token pair", Request: handler.RefreshTokenRequest{}, Response: handler.TokenData{}},
+	{Method: "POST", Path: "/api/v1/auth/validate", OperationID: "validateToken", Tags: []string{"auth"},
+		Summary: "Validate an access token", Request: handler.ValidateTokenRequest{}, Response: handler.ValidateTokenData{}},
+	{Method: "POST", Path: "/api/v1/auth/logout", OperationID: "logout", Tags: []string{"auth"},
+		Summary: "Revoke an access token and its refresh token", Request: handler.LogoutRequest{}, Response: handler.SuccessData{}},
+
+	// Articles
+	{Method: "POST", Path: "/api/v1/articles", OperationID: "createArticle", Tags: []string{"articles"},
+		Summary: "Create an article", Auth: true,
+		Params: []openapi.Parameter{idempotencyKeyParam}, Request: handler.CreateArticleRequest{}, Response: handler.ArticleData{}},
+	{Method: "GET", Path: "/api/v1/articles", OperationID: "listArticles", Tags: []string{"articles"},
+		Summary: "List articles with their author",
+		Params: append(append([]openapi.Parameter(nil), pageParams...), openapi.Parameter{
+			Name: "user_id", In: "query", Description: "Only articles of this user", Schema: &openapi.Schema{Type: "integer", Format: "int32", Minimum: openapi.Bound(1)},
+		}),
+		Response: handler.ArticleData{}, List: true},
+	{Method: "GET", Path: "/api/v1/articles/{id}", OperationID: "getArticle", Tags: []string{"articles"},
+		Summary: "Get an article with its author", Params: []openapi.Parameter{idParam, ifNoneMatchParam}, Response: handler.ArticleWithUserData{}},
+	{Method: "PUT", Path: "/api/v1/articles/{id}", OperationID: "updateArticle", Tags: []string{"articles"},
+		Summary: "Replace the title and content of an article",
+		Params:  []openapi.Parameter{idParam, ifMatchParam}, Request: handler.UpdateArticleRequest{}, Response: handler.ArticleData{}},
+	{Method: "PATCH", Path: "/api/v1/articles/{id}", OperationID: "patchArticle", Tags: []string{"articles"},
+		Summary:     "Partially update an article",
+		Description: "RFC 7396 JSON merge patch applied over the current title and content, absent members are unchanged.",
+		Params:      []openapi.Parameter{idParam, ifMatchParam}, Request: handler.PatchArticleRequest{}, Response: handler.ArticleData{}},
+	{Method: "DELETE", Path: "/api/v1/articles/{id}", OperationID: "deleteArticle", Tags: []string{"articles"},
+		Summary: "Delete an article", Params: []openapi.Parameter{idParam, ifMatchParam}, Response: handler.SuccessData{}},
+}
+
+// registerOpenAPIRoutes generates the document of every route registered so far and
+// serves it at /openapi.json, with an interactive page at /docs
+func registerOpenAPIRoutes(router *mux.Router) (*openapi.Document, error) {
+	info := openapi.Info{
+		Title:       "API Gateway",
+		Version:     "1.0.0",
+		Description: "REST gateway of the User and Article services. Every response uses the {code, message, data} envelope.",
+	}
+	doc, err := openapi.Generate(info, router, apiRoutes)
+	if err != nil {
+		return nil, err
+	}
+
+	router.Handle("/openapi.json", openapi.Handler(doc)).Methods("GET")
+	router.Handle("/docs", openapi.UIHandler(info.Title, "/openapi.json")).Methods("GET")
+	return doc, nil
+}
+
+// loadRequestValidator returns the validator of incoming requests: the schema file
+// OPENAPI_SCHEMA_FILE if set, else the generated document. REQUEST_VALIDATION=false disables it.
+func loadRequestValidator(generated *openapi.Document) (*openapi.Validator, error) {
+	if !getEnvBool("REQUEST_VALIDATION", true) {
+		return nil, nil
+	}
+	path := getEnv("OPENAPI_SCHEMA_FILE", "")
+	if path == "" {
+		return openapi.NewValidator(generated), nil
+	}
+	doc, err := openapi.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return openapi.NewValidator(doc), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/thatlq1812/service-3-gateway/internal/handler"
+	"github.com/thatlq1812/service-3-gateway/internal/health"
+)
+
+// TestEveryRouteHasOperation builds the router of main and checks that the generated
+// document has an operation for every registered route
+func TestEveryRouteHasOperation(t *testing.T) {
+	router := mux.NewRouter()
+	registerAPIRoutes(router, handler.NewUserHandler(nil), handler.NewArticleHandler(nil))
+	registerHealthRoutes(router, health.NewMonitor(time.Second), time.Second)
+
+	type route struct{ method, path string }
+	var routes []route
+	err := router.Walk(func(r *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if r.GetHandler() == nil {
+			return nil
+		}
+		path, err := r.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := r.GetMethods()
+		if err != nil {
+			t.Errorf("route %s has no methods", path)
+			return nil
+		}
+		for _, method := range methods {
+			routes = append(routes, route{method, path})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Walk: %v", err)
+	}
+	if len(routes) == 0 {
+		t.Fatal("no routes registered")
+	}
+
+	doc, err := registerOpenAPIRoutes(router)
+	if err != nil {
+		t.Fatalf("registerOpenAPIRoutes: %v", err)
+	}
+
+	for _, r := range routes {
+		operation := doc.Paths[pathParam(r.path)][strings.ToLower(r.method)]
+		if operation == nil {
+			t.Errorf("%s %s has no operation in the OpenAPI document", r.method, r.path)
+			continue
+		}
+		if operation.OperationID == "" {
+			t.Errorf("%s %s has an operation without operationId", r.method, r.path)
+		}
+	}
+}
+
+// pathParam strips the patterns of mux path variables, e.g. {id:[0-9]+} to {id}
+func pathParam(path string) string {
+	var b strings.Builder
+	for len(path) > 0 {
+		open := strings.IndexByte(path, '{')
+		if open < 0 {
+			b.WriteString(path)
+			break
+		}
+		end := strings.IndexByte(path[open:], '}')
+		if end < 0 {
+			b.WriteString(path)
+			break
+		}
+		name, _, _ := strings.Cut(path[open+1:open+end], ":")
+		b.WriteString(path[:open] + "{" + name + "}")
+		path = path[open+end+1:]
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/thatlq1812/service-3-gateway/internal/tlsconfig"
+)
+
+// loadServerTLS reads TLS termination settings from environment.
+// Returns nil when TLS_CERT_FILE is not set (plain HTTP).
+//
+//	TLS_CERT_FILE, TLS_KEY_FILE   default certificate
+//	TLS_EXTRA_CERTS               more certificates for SNI: "cert.pem:key.pem,cert2.pem:key2.pem"
+//	TLS_MIN_VERSION               1.2 (default) or 1.3
+//	TLS_CIPHER_SUITES             comma-separated IANA names (TLS 1.2 only)
+//	TLS_CLIENT_CA_FILE            enables client certificate verification
+//	TLS_CLIENT_AUTH               request (default with a CA file) or require
+func loadServerTLS() (*tlsconfig.ServerConfig, error) {
+	certFile := getEnv("TLS_CERT_FILE", "")
+	if certFile == "" {
+		return nil, nil
+	}
+
+	cfg := &tlsconfig.ServerConfig{}
+
+	pair, err := tlsconfig.LoadKeyPair(certFile, getEnv("TLS_KEY_FILE", ""))
+	if err != nil {
+		return nil, err
+	}
+	cfg.Certificates = append(cfg.Certificates, pair)
+
+	for _, entry := range getEnvList("TLS_EXTRA_CERTS") {
+		files := strings.SplitN(entry, ":", 2)
+		if len(files) != 2 {
+			return nil, fmt.Errorf("TLS_EXTRA_CERTS entry %q must be cert:key", entry)
+		}
+		pair, err := tlsconfig.LoadKeyPair(files[0], files[1])
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = append(cfg.Certificates, pair)
+	}
+
+	if cfg.MinVersion, err = tlsconfig.ParseVersion(getEnv("TLS_MIN_VERSION", "1.2")); err != nil {
+		return nil, err
+	}
+	if cfg.CipherSuites, err = tlsconfig.ParseCipherSuites(getEnv("TLS_CIPHER_SUITES", "")); err != nil {
+		return nil, err
+	}
+
+	if caFile := getEnv("TLS_CLIENT_CA_FILE", ""); caFile != "" {
+		if cfg.ClientCAs, err = tlsconfig.LoadCertPool(caFile); err != nil {
+			return nil, err
+		}
+		if cfg.ClientAuth, err = tlsconfig.ParseClientAuth(getEnv("TLS_CLIENT_AUTH", "request")); err != nil {
+			return nil, err
+		}
+	}
+
+	return cfg, nil
+}
+
+// loadClientPrincipals reads TLS_CLIENT_PRINCIPALS ("subject=principal;subject2=principal2"),
+// where subject is a certificate common name or full DN
+func loadClientPrincipals() map[string]string {
+	principals := make(map[string]string)
+	for _, entry := range strings.Split(getEnv("TLS_CLIENT_PRINCIPALS", ""), ";") {
+		// Split on the last "=" since a DN contains "=" itself
+		i := strings.LastIndex(entry, "=")
+		if i <= 0 {
+			continue
+		}
+		principals[strings.TrimSpace(entry[:i])] = strings.TrimSpace(entry[i+1:])
+	}
+	return principals
+}
+
+// logTLSReload reports the result of a certificate reload
+func logTLSReload(name string, err error) {
+	if err != nil {
+		slog.Error("TLS reload failed, keeping previous version", "name", name, "error", err)
+		return
+	}
+	slog.Info("TLS reloaded", "name", name)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/thatlq1812/service-3-gateway/internal/tracing"
+)
+
+// loadTracer builds the tracer from environment, or returns nil when tracing is disabled
+func loadTracer() (*tracing.Tracer, error) {
+	var exporter tracing.Exporter
+	switch mode := strings.ToLower(getEnv("TRACING_EXPORTER", "none")); mode {
+	case "none":
+		return nil, nil
+	case "stdout":
+		exporter = tracing.NewWriterExporter(os.Stdout)
+	case "file":
+		path := getEnv("TRACING_FILE", "traces.jsonl")
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("open trace file: %w", err)
+		}
+		exporter = tracing.NewWriterExporter(f)
+	case "otlp":
+		headers := make(map[string]string)
+		for _, pair := range getEnvList("TRACING_OTLP_HEADERS") {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid TRACING_OTLP_HEADERS entry %q (want key=value)", pair)
+			}
+			headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+		exporter = tracing.NewOTLPExporter(getEnv("TRACING_OTLP_ENDPOINT", "http://localhost:4318/v1/traces"), headers)
+	default:
+		return nil, fmt.Errorf("unknown TRACING_EXPORTER %q (want none, stdout, file or otlp)", mode)
+	}
+
+	ratio := 1.0
+	if value := os.Getenv("TRACING_SAMPLE_RATIO"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 || parsed > 1 {
+			return nil, fmt.Errorf("invalid TRACING_SAMPLE_RATIO %q (want 0..1)", value)
+		}
+		ratio = parsed
+	}
+
+	return tracing.NewTracer(getEnv("TRACING_SERVICE_NAME", "api-gateway"), exporter, ratio), nil
+}
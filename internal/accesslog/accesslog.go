@@ -0,0 +1,161 @@
+package accesslog
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Output formats
+const (
+	FormatCombined = "combined" // Apache/NGINX Combined Log Format
+	FormatJSON     = "json"     // One JSON object per line
+)
+
+// Entry is one served request
+type Entry struct {
+	Time      time.Time // Request start
+	RemoteIP  string
+	Principal string // Authenticated caller, "" if anonymous
+	Method    string
+	URI       string // Request URI with secrets in the query masked
+	Proto     string
+	Route     string
+	Status    int
+	Bytes     int64
+	Duration  time.Duration
+	Referer   string
+	UserAgent string
+	RequestID string
+}
+
+// Logger writes access log lines in one format, sampling successful requests.
+// It is safe for concurrent use.
+type Logger struct {
+	mu         sync.Mutex
+	w          io.Writer
+	format     string
+	sampleRate float64
+}
+
+// New returns an access logger writing to w. sampleRate in [0, 1] is the fraction
+// of successful (status < 400) requests kept; errors are always written.
+func New(w io.Writer, format string, sampleRate float64) (*Logger, error) {
+	if format != FormatCombined && format != FormatJSON {
+		return nil, fmt.Errorf("unknown access log format %q (want %s or %s)", format, FormatCombined, FormatJSON)
+	}
+	if sampleRate < 0 || sampleRate > 1 {
+		return nil, fmt.Errorf("access log sample rate %v out of range [0, 1]", sampleRate)
+	}
+	return &Logger{w: w, format: format, sampleRate: sampleRate}, nil
+}
+
+// Log writes the entry unless it is sampled out
+func (l *Logger) Log(e Entry) error {
+	if e.Status < 400 && l.sampleRate < 1 && rand.Float64() >= l.sampleRate {
+		return nil
+	}
+
+	var line []byte
+	if l.format == FormatJSON {
+		line = formatJSON(e)
+	} else {
+		line = formatCombined(e)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.w.Write(line)
+	return err
+}
+
+// formatCombined renders %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-Agent}i"
+func formatCombined(e Entry) []byte {
+	bytes := "-"
+	if e.Bytes > 0 {
+		bytes = strconv.FormatInt(e.Bytes, 10)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(dash(e.RemoteIP))
+	sb.WriteString(" - ")
+	sb.WriteString(dash(strings.ReplaceAll(e.Principal, " ", "_")))
+	sb.WriteString(" [")
+	sb.WriteString(e.Time.Format("02/Jan/2006:15:04:05 -0700"))
+	sb.WriteString("] ")
+	sb.WriteString(quote(e.Method + " " + e.URI + " " + e.Proto))
+	sb.WriteString(" ")
+	sb.WriteString(strconv.Itoa(e.Status))
+	sb.WriteString(" ")
+	sb.WriteString(bytes)
+	sb.WriteString(" ")
+	sb.WriteString(quote(dash(e.Referer)))
+	sb.WriteString(" ")
+	sb.WriteString(quote(dash(e.UserAgent)))
+	sb.WriteString("\n")
+	return []byte(sb.String())
+}
+
+func formatJSON(e Entry) []byte {
+	line, _ := json.Marshal(struct {
+		Time       string  `json:"time"`
+		RemoteIP   string  `json:"remote_ip"`
+		Principal  string  `json:"principal,omitempty"`
+		Method     string  `json:"method"`
+		URI        string  `json:"uri"`
+		Proto      string  `json:"proto"`
+		Route      string  `json:"route"`
+		Status     int     `json:"status"`
+		Bytes      int64   `json:"bytes"`
+		DurationMS float64 `json:"duration_ms"`
+		Referer    string  `json:"referer,omitempty"`
+		UserAgent  string  `json:"user_agent,omitempty"`
+		RequestID  string  `json:"request_id,omitempty"`
+	}{
+		Time:       e.Time.UTC().Format(time.RFC3339Nano),
+		RemoteIP:   e.RemoteIP,
+		Principal:  e.Principal,
+		Method:     e.Method,
+		URI:        e.URI,
+		Proto:      e.Proto,
+		Route:      e.Route,
+		Status:     e.Status,
+		Bytes:      e.Bytes,
+		DurationMS: float64(e.Duration.Microseconds()) / 1000,
+		Referer:    e.Referer,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+	})
+	return append(line, '\n')
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// quote escapes quotes, backslashes and control characters so a client cannot forge log fields
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, c := range s {
+		switch {
+		case c == '"' || c == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(c)
+		case c < 0x20 || c == 0x7f:
+			fmt.Fprintf(&sb, "\\x%02x", c)
+		default:
+			sb.WriteRune(c)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
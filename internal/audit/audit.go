@@ -0,0 +1,174 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/rotate"
+)
+
+// Action is an audited operation on a resource
+type Action struct {
+	Name     string // Backend operation, e.g. "UpdateUser"
+	Resource string // Target type, e.g. "user"
+}
+
+// Entry is one line of the audit log. Entries are hash-chained: Hash covers every
+// other field, including PrevHash, so editing, removing or reordering lines breaks the chain.
+type Entry struct {
+	Seq             uint64    `json:"seq"`
+	Time            time.Time `json:"time"`
+	Action          string    `json:"action"`
+	Resource        string    `json:"resource"`
+	TargetID        string    `json:"target_id,omitempty"`
+	Principal       string    `json:"principal"`
+	PrincipalSource string    `json:"principal_source"` // "mtls", "jwt" or "anonymous"
+	ClientIP        string    `json:"client_ip"`
+	Fields          []string  `json:"fields,omitempty"` // Names of the fields sent, never their values
+	Status          int       `json:"status"`
+	Code            string    `json:"code"`
+	RequestID       string    `json:"request_id,omitempty"`
+	PrevHash        string    `json:"prev_hash"`
+	Hash            string    `json:"hash"`
+}
+
+// ComputeHash returns the chain hash of the entry: SHA-256 of its JSON encoding with Hash empty
+func (e Entry) ComputeHash() (string, error) {
+	e.Hash = ""
+	b, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Logger appends entries to a rotating JSON-lines file.
+// The chain continues across rotated files and restarts.
+type Logger struct {
+	mu       sync.Mutex
+	w        *rotate.Writer
+	seq      uint64
+	lastHash string
+}
+
+// Open opens the audit log and resumes the chain from its last entry
+func Open(cfg rotate.Config) (*Logger, error) {
+	last, err := lastEntry(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("audit: resume chain: %w", err)
+	}
+
+	w, err := rotate.Open(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Logger{w: w, seq: last.Seq, lastHash: last.Hash}, nil
+}
+
+// Log stamps the entry with time, sequence number and hashes, then writes and syncs it
+func (l *Logger) Log(e Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+	e.Seq = l.seq + 1
+	e.PrevHash = l.lastHash
+
+	hash, err := e.ComputeHash()
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	e.Hash = hash
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	if _, err := l.w.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+	if err := l.w.Sync(); err != nil {
+		return fmt.Errorf("audit: %w", err)
+	}
+
+	l.seq, l.lastHash = e.Seq, e.Hash
+	return nil
+}
+
+// Close closes the audit log file
+func (l *Logger) Close() error {
+	return l.w.Close()
+}
+
+// Files returns the rotated audit files of path followed by path itself, in chain order
+func Files(path string) ([]string, error) {
+	files, err := rotate.Backups(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	return files, nil
+}
+
+// lastEntry returns the newest entry of the log, or a zero Entry for a new log
+func lastEntry(path string) (Entry, error) {
+	files, err := Files(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	// The current file can be empty right after a rotation
+	for i := len(files) - 1; i >= 0; i-- {
+		line, err := lastLine(files[i])
+		if err != nil {
+			return Entry{}, err
+		}
+		if line == nil {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return Entry{}, fmt.Errorf("%s: last entry: %w", files[i], err)
+		}
+		return e, nil
+	}
+	return Entry{}, nil
+}
+
+// lastLine returns the last non-empty line of the file
+func lastLine(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		if line := bytes.TrimSpace(scanner.Bytes()); len(line) > 0 {
+			last = append(last[:0], line...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return last, nil
+}
+
+// maxLineSize bounds a single audit line when reading the log back
+const maxLineSize = 1 << 20
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// VerifyResult summarizes a verified chain
+type VerifyResult struct {
+	Entries  int
+	FirstSeq uint64
+	LastSeq  uint64
+	LastHash string
+	// Anchored is false when the first entry is not the genesis entry (seq 1),
+	// e.g. because old rotated files were removed; the chain is then only verified from FirstSeq.
+	Anchored bool
+}
+
+// VerifyError locates the first entry breaking the chain
+type VerifyError struct {
+	File   string
+	Line   int
+	Reason string
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Reason)
+}
+
+// Verify checks the chain across files, given in chain order (see Files).
+// It fails on the first entry whose hash, sequence number or link to the previous entry is wrong.
+func Verify(files ...string) (VerifyResult, error) {
+	var result VerifyResult
+	var prev *Entry
+
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return result, err
+		}
+
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+		lineNo := 0
+		for scanner.Scan() {
+			lineNo++
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			fail := func(format string, args ...any) (VerifyResult, error) {
+				f.Close()
+				return result, &VerifyError{File: file, Line: lineNo, Reason: fmt.Sprintf(format, args...)}
+			}
+
+			var e Entry
+			if err := json.Unmarshal(line, &e); err != nil {
+				return fail("invalid entry: %v", err)
+			}
+			hash, err := e.ComputeHash()
+			if err != nil {
+				return fail("hash entry: %v", err)
+			}
+			if hash != e.Hash {
+				return fail("seq %d: hash mismatch, entry was modified", e.Seq)
+			}
+
+			if prev == nil {
+				result.FirstSeq = e.Seq
+				result.Anchored = e.Seq == 1 && e.PrevHash == ""
+				if e.Seq == 1 && e.PrevHash != "" {
+					return fail("seq 1: genesis entry has a previous hash")
+				}
+			} else {
+				if e.Seq != prev.Seq+1 {
+					return fail("seq %d follows seq %d, entries were removed or reordered", e.Seq, prev.Seq)
+				}
+				if e.PrevHash != prev.Hash {
+					return fail("seq %d: previous hash does not match seq %d", e.Seq, prev.Seq)
+				}
+			}
+
+			result.Entries++
+			result.LastSeq = e.Seq
+			result.LastHash = e.Hash
+			prev = &e
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", file, err)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,47 @@
+package backend
+
+import (
+	"fmt"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+// Config describes how the gateway reaches one backend service
+type Config struct {
+	Name     string            // Service name used in health output, e.g. "user-service"
+	Address  string            // "host:port", "host1:port,host2:port" or a target such as "dns:///host:port" or "registry:///user-service"
+	Policy   string            // PolicyRoundRobin or PolicyLeastRequest
+	Prefer   map[string]string // Registry metadata to prefer when routing, e.g. {"zone": "hcm-1"}
+	Outlier  OutlierConfig
+	Registry *Registry // Resolves "registry:///" addresses, nil when no registry is configured
+}
+
+// Target returns the gRPC dial target of the backend
+func (c Config) Target() string {
+	return Target(c.Address)
+}
+
+// DialOptions returns the resolver and load balancing options for dialing the backend
+func (c Config) DialOptions() ([]grpc.DialOption, error) {
+	policy := c.Policy
+	if policy == "" {
+		policy = PolicyRoundRobin
+	}
+	if policy != PolicyRoundRobin && policy != PolicyLeastRequest {
+		return nil, fmt.Errorf("unknown load balancing policy %q", policy)
+	}
+
+	resolvers := []resolver.Builder{staticResolverBuilder{}}
+	if c.Registry != nil {
+		resolvers = append(resolvers, c.Registry)
+	} else if strings.HasPrefix(c.Address, registryScheme+":") {
+		return nil, fmt.Errorf("address %q needs a registry path", c.Address)
+	}
+
+	return []grpc.DialOption{
+		grpc.WithResolvers(resolvers...),
+		grpc.WithDefaultServiceConfig(serviceConfigJSON(c.Name, policy, c.Prefer, c.Outlier)),
+	}, nil
+}
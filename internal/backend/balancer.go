@@ -0,0 +1,348 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/serviceconfig"
+)
+
+// BalancerName is the gRPC load balancing policy registered by this package
+const BalancerName = "gateway_balancer"
+
+// Load balancing policies supported by the gateway balancer
+const (
+	PolicyRoundRobin   = "round_robin"
+	PolicyLeastRequest = "least_request"
+)
+
+func init() {
+	balancer.Register(&balancerBuilder{})
+}
+
+// balancerConfig is the loadBalancingConfig of the gateway balancer
+type balancerConfig struct {
+	serviceconfig.LoadBalancingConfig `json:"-"`
+
+	Service             string            `json:"service"`
+	Policy              string            `json:"policy"`
+	Prefer              map[string]string `json:"prefer,omitempty"`
+	ConsecutiveFailures *uint32           `json:"consecutiveFailures,omitempty"` // Nil uses the default, 0 disables ejection
+	EjectionTime        string            `json:"ejectionTime"`
+	MaxEjectionPercent  *int              `json:"maxEjectionPercent,omitempty"` // Nil uses the default
+}
+
+// serviceConfigJSON builds the default service config selecting the gateway balancer
+func serviceConfigJSON(service, policy string, prefer map[string]string, outlier OutlierConfig) string {
+	cfg := map[string]interface{}{
+		"loadBalancingConfig": []interface{}{
+			map[string]interface{}{
+				BalancerName: balancerConfig{
+					Service:             service,
+					Policy:              policy,
+					Prefer:              prefer,
+					ConsecutiveFailures: &outlier.ConsecutiveFailures,
+					EjectionTime:        outlier.EjectionTime.String(),
+					MaxEjectionPercent:  &outlier.MaxEjectionPercent,
+				},
+			},
+		},
+	}
+	data, _ := json.Marshal(cfg)
+	return string(data)
+}
+
+// balancerBuilder builds the gateway balancer: weighted round robin or least request
+// across ready endpoints, preferring endpoints whose registry metadata matches the
+// config and skipping endpoints ejected as outliers
+type balancerBuilder struct{}
+
+func (*balancerBuilder) Name() string {
+	return BalancerName
+}
+
+func (*balancerBuilder) ParseConfig(data json.RawMessage) (serviceconfig.LoadBalancingConfig, error) {
+	cfg := &balancerConfig{}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: invalid config: %w", BalancerName, err)
+	}
+	switch cfg.Policy {
+	case "":
+		cfg.Policy = PolicyRoundRobin
+	case PolicyRoundRobin, PolicyLeastRequest:
+	default:
+		return nil, fmt.Errorf("%s: unknown policy %q", BalancerName, cfg.Policy)
+	}
+	if cfg.EjectionTime != "" {
+		if _, err := time.ParseDuration(cfg.EjectionTime); err != nil {
+			return nil, fmt.Errorf("%s: invalid ejectionTime: %w", BalancerName, err)
+		}
+	}
+	return cfg, nil
+}
+
+func (bb *balancerBuilder) Build(cc balancer.ClientConn, opts balancer.BuildOptions) balancer.Balancer {
+	b := &gatewayBalancer{pickers: &pickerBuilder{}}
+	tcc := &trackingClientConn{ClientConn: cc, pickers: b.pickers}
+	b.Balancer = base.NewBalancerBuilder(BalancerName, b.pickers, base.Config{HealthCheck: true}).Build(tcc, opts)
+	return b
+}
+
+// gatewayBalancer is a base balancer that applies its config to the picker builder
+// and keeps the endpoint set in sync with resolver updates
+type gatewayBalancer struct {
+	balancer.Balancer
+	pickers *pickerBuilder
+}
+
+func (b *gatewayBalancer) UpdateClientConnState(s balancer.ClientConnState) error {
+	if cfg, ok := s.BalancerConfig.(*balancerConfig); ok {
+		b.pickers.configure(cfg)
+	}
+
+	b.pickers.endpoints().setAddresses(s.ResolverState.Addresses)
+
+	return b.Balancer.UpdateClientConnState(s)
+}
+
+// trackingClientConn records the connectivity state of every SubConn in the endpoint set
+type trackingClientConn struct {
+	balancer.ClientConn
+	pickers *pickerBuilder
+}
+
+func (cc *trackingClientConn) NewSubConn(addrs []resolver.Address, opts balancer.NewSubConnOptions) (balancer.SubConn, error) {
+	listener := opts.StateListener
+	addr := ""
+	if len(addrs) > 0 {
+		addr = addrs[0].Addr
+	}
+	opts.StateListener = func(s balancer.SubConnState) {
+		cc.pickers.endpoints().setState(addr, s.ConnectivityState, s.ConnectionError)
+		listener(s)
+	}
+	return cc.ClientConn.NewSubConn(addrs, opts)
+}
+
+// pickerBuilder creates pickers over the ready SubConns using the configured policy
+type pickerBuilder struct {
+	mu     sync.RWMutex
+	policy string
+	prefer map[string]string
+	set    *EndpointSet
+}
+
+// configure applies a parsed balancer config
+func (pb *pickerBuilder) configure(cfg *balancerConfig) {
+	outlier := DefaultOutlierConfig()
+	if cfg.ConsecutiveFailures != nil {
+		outlier.ConsecutiveFailures = *cfg.ConsecutiveFailures
+	}
+	if d, err := time.ParseDuration(cfg.EjectionTime); err == nil && d > 0 {
+		outlier.EjectionTime = d
+	}
+	if cfg.MaxEjectionPercent != nil {
+		outlier.MaxEjectionPercent = *cfg.MaxEjectionPercent
+	}
+
+	set := Endpoints(cfg.Service)
+	set.setOutlier(outlier)
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.policy = cfg.Policy
+	pb.prefer = cfg.Prefer
+	pb.set = set
+}
+
+// endpoints returns the endpoint set, or an unnamed one before the config arrives
+func (pb *pickerBuilder) endpoints() *EndpointSet {
+	pb.mu.RLock()
+	set := pb.set
+	pb.mu.RUnlock()
+	if set != nil {
+		return set
+	}
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	if pb.set == nil {
+		pb.set = Endpoints("")
+	}
+	return pb.set
+}
+
+func (pb *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	set := pb.endpoints()
+	pb.mu.RLock()
+	policy, prefer := pb.policy, pb.prefer
+	pb.mu.RUnlock()
+
+	p := &picker{policy: policy, set: set}
+	for sc, scInfo := range info.ReadySCs {
+		ep := set.get(scInfo.Address.Addr)
+		info := ep.endpointInfo()
+		p.subConns = append(p.subConns, pickable{
+			subConn:   sc,
+			endpoint:  ep,
+			weight:    info.weight(),
+			preferred: len(prefer) > 0 && info.matches(prefer),
+		})
+	}
+	// Map iteration order is random, keep a stable order for the schedule
+	sort.Slice(p.subConns, func(i, j int) bool {
+		return p.subConns[i].endpoint.address < p.subConns[j].endpoint.address
+	})
+	p.schedule = weightedSchedule(p.subConns)
+
+	// Start at a random position so gateway restarts do not all hit the first endpoint
+	p.next.Store(rand.Uint32())
+	return p
+}
+
+// maxWeight bounds the schedule length of weighted round robin
+const maxWeight = 100
+
+// weightedSchedule spreads SubConn indexes proportionally to their weight
+// using smooth weighted round robin, e.g. weights 3,1 give a,a,b,a
+func weightedSchedule(subConns []pickable) []int {
+	total := 0
+	weights := make([]int, len(subConns))
+	for i, pc := range subConns {
+		weights[i] = int(min(pc.weight, maxWeight))
+		total += weights[i]
+	}
+
+	schedule := make([]int, 0, total)
+	current := make([]int, len(subConns))
+	for range total {
+		best := 0
+		for i := range subConns {
+			current[i] += weights[i]
+			if current[i] > current[best] {
+				best = i
+			}
+		}
+		current[best] -= total
+		schedule = append(schedule, best)
+	}
+	return schedule
+}
+
+// pickable is a ready SubConn with its endpoint statistics
+type pickable struct {
+	subConn   balancer.SubConn
+	endpoint  *endpoint
+	weight    uint32
+	preferred bool
+}
+
+// picker chooses a ready, non-ejected SubConn for every call
+type picker struct {
+	policy   string
+	set      *EndpointSet
+	subConns []pickable
+	schedule []int
+	next     atomic.Uint32
+}
+
+func (p *picker) Pick(balancer.PickInfo) (balancer.PickResult, error) {
+	allowed := p.allowed()
+
+	var chosen pickable
+	switch p.policy {
+	case PolicyLeastRequest:
+		// Power of two choices: the less loaded of two random endpoints, relative to weight
+		candidates := make([]pickable, 0, len(p.subConns))
+		for i, pc := range p.subConns {
+			if allowed[i] {
+				candidates = append(candidates, pc)
+			}
+		}
+		chosen = candidates[rand.IntN(len(candidates))]
+		if len(candidates) > 1 {
+			other := candidates[rand.IntN(len(candidates))]
+			if other.load() < chosen.load() {
+				chosen = other
+			}
+		}
+	default:
+		// Walk the weighted schedule from one shared position until an allowed SubConn
+		// comes up; a local walk sees every position even under concurrent picks
+		n := uint32(len(p.schedule))
+		start := p.next.Add(1)
+		found := false
+		for k := uint32(0); k < n; k++ {
+			if i := p.schedule[(start+k)%n]; allowed[i] {
+				chosen, found = p.subConns[i], true
+				break
+			}
+		}
+		if !found {
+			// The schedule lists every SubConn, but never leave chosen empty
+			for i := range p.subConns {
+				if allowed[i] {
+					chosen = p.subConns[i]
+					break
+				}
+			}
+		}
+	}
+
+	ep := chosen.endpoint
+	ep.begin()
+	return balancer.PickResult{
+		SubConn: chosen.subConn,
+		Done: func(info balancer.DoneInfo) {
+			p.set.finish(ep, info.Err)
+		},
+	}, nil
+}
+
+// allowed marks the SubConns that may be picked: preferred ones if any is available,
+// otherwise every non-ejected one, otherwise all of them
+func (p *picker) allowed() []bool {
+	now := time.Now()
+	available := make([]bool, len(p.subConns))
+	preferred := make([]bool, len(p.subConns))
+	anyAvailable, anyPreferred := false, false
+
+	for i, pc := range p.subConns {
+		if pc.endpoint.ejected(now) {
+			continue
+		}
+		available[i], anyAvailable = true, true
+		if pc.preferred {
+			preferred[i], anyPreferred = true, true
+		}
+	}
+
+	switch {
+	case anyPreferred:
+		return preferred
+	case anyAvailable:
+		return available
+	default:
+		for i := range available {
+			available[i] = true
+		}
+		return available
+	}
+}
+
+// load returns the in-flight calls of a SubConn relative to its weight
+func (pc pickable) load() float64 {
+	return float64(pc.endpoint.inFlightCalls()) / float64(pc.weight)
+}
@@ -0,0 +1,287 @@
+package backend
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/resolver"
+	"google.golang.org/grpc/status"
+)
+
+// OutlierConfig controls ejection of endpoints that keep failing
+type OutlierConfig struct {
+	ConsecutiveFailures uint32        // Failures in a row before ejection, 0 disables ejection
+	EjectionTime        time.Duration // Base ejection time, multiplied by the number of ejections
+	MaxEjectionPercent  int           // Upper bound of endpoints ejected at the same time
+}
+
+// DefaultOutlierConfig returns the default outlier detection settings
+func DefaultOutlierConfig() OutlierConfig {
+	return OutlierConfig{
+		ConsecutiveFailures: 5,
+		EjectionTime:        30 * time.Second,
+		MaxEjectionPercent:  50,
+	}
+}
+
+// maxEjectionTime caps the growing ejection time of a repeatedly failing endpoint
+const maxEjectionTime = 5 * time.Minute
+
+// EndpointStatus is a snapshot of one backend endpoint, reported by /health
+type EndpointStatus struct {
+	Address             string            `json:"address"`
+	Weight              uint32            `json:"weight"`
+	Metadata            map[string]string `json:"metadata,omitempty"`
+	State               string            `json:"state"`
+	Ejected             bool              `json:"ejected"`
+	EjectedUntil        *time.Time        `json:"ejected_until,omitempty"`
+	ConsecutiveFailures uint32            `json:"consecutive_failures"`
+	InFlight            int64             `json:"in_flight"`
+	LastError           string            `json:"last_error,omitempty"`
+}
+
+// endpoint holds the connectivity state and call statistics of one address.
+// It outlives pickers, so statistics survive picker rebuilds.
+type endpoint struct {
+	address string
+
+	mu                  sync.Mutex
+	info                EndpointInfo
+	state               connectivity.State
+	lastError           string
+	inFlight            int64
+	consecutiveFailures uint32
+	ejections           int
+	ejectedUntil        time.Time
+}
+
+// EndpointSet tracks every endpoint of one backend service
+type EndpointSet struct {
+	service string
+
+	mu        sync.RWMutex
+	outlier   OutlierConfig
+	endpoints map[string]*endpoint
+}
+
+var (
+	setsMu sync.Mutex
+	sets   = make(map[string]*EndpointSet)
+)
+
+// Endpoints returns the endpoint set of a backend service, creating it if needed.
+// The set is filled in by the gateway balancer once the service is dialed.
+func Endpoints(service string) *EndpointSet {
+	setsMu.Lock()
+	defer setsMu.Unlock()
+
+	set, ok := sets[service]
+	if !ok {
+		set = &EndpointSet{
+			service:   service,
+			outlier:   DefaultOutlierConfig(),
+			endpoints: make(map[string]*endpoint),
+		}
+		sets[service] = set
+	}
+	return set
+}
+
+// Status returns a snapshot of every endpoint, sorted by address
+func (s *EndpointSet) Status() []EndpointStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	now := time.Now()
+	result := make([]EndpointStatus, 0, len(s.endpoints))
+	for _, ep := range s.endpoints {
+		ep.mu.Lock()
+		st := EndpointStatus{
+			Address:             ep.address,
+			Weight:              ep.info.weight(),
+			Metadata:            ep.info.Metadata,
+			State:               ep.state.String(),
+			Ejected:             now.Before(ep.ejectedUntil),
+			ConsecutiveFailures: ep.consecutiveFailures,
+			InFlight:            ep.inFlight,
+			LastError:           ep.lastError,
+		}
+		if st.Ejected {
+			until := ep.ejectedUntil
+			st.EjectedUntil = &until
+		}
+		ep.mu.Unlock()
+		result = append(result, st)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Address < result[j].Address })
+	return result
+}
+
+// setOutlier replaces the outlier detection settings
+func (s *EndpointSet) setOutlier(cfg OutlierConfig) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.outlier = cfg
+}
+
+// setAddresses keeps endpoints for the given addresses, updates their registry
+// info and drops all others
+func (s *EndpointSet) setAddresses(addrs []resolver.Address) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keep := make(map[string]bool, len(addrs))
+	for _, addr := range addrs {
+		keep[addr.Addr] = true
+		ep, ok := s.endpoints[addr.Addr]
+		if !ok {
+			ep = &endpoint{address: addr.Addr, state: connectivity.Idle}
+			s.endpoints[addr.Addr] = ep
+		}
+		ep.mu.Lock()
+		ep.info = endpointInfoOf(addr)
+		ep.mu.Unlock()
+	}
+	for addr := range s.endpoints {
+		if !keep[addr] {
+			delete(s.endpoints, addr)
+		}
+	}
+}
+
+// get returns the endpoint of an address, creating it if needed
+func (s *EndpointSet) get(addr string) *endpoint {
+	s.mu.RLock()
+	ep, ok := s.endpoints[addr]
+	s.mu.RUnlock()
+	if ok {
+		return ep
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ep, ok = s.endpoints[addr]; !ok {
+		ep = &endpoint{address: addr, state: connectivity.Idle}
+		s.endpoints[addr] = ep
+	}
+	return ep
+}
+
+// setState records a connectivity state change of an endpoint
+func (s *EndpointSet) setState(addr string, state connectivity.State, err error) {
+	if state == connectivity.Shutdown {
+		return
+	}
+
+	ep := s.get(addr)
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	ep.state = state
+	if err != nil {
+		ep.lastError = err.Error()
+	}
+}
+
+// ejected reports whether an endpoint is currently ejected
+func (ep *endpoint) ejected(now time.Time) bool {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return now.Before(ep.ejectedUntil)
+}
+
+// endpointInfo returns the registry info of an endpoint
+func (ep *endpoint) endpointInfo() EndpointInfo {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.info
+}
+
+// begin records the start of a call on an endpoint
+func (ep *endpoint) begin() {
+	ep.mu.Lock()
+	ep.inFlight++
+	ep.mu.Unlock()
+}
+
+// inFlightCalls returns the number of calls in progress on an endpoint
+func (ep *endpoint) inFlightCalls() int64 {
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	return ep.inFlight
+}
+
+// finish records the result of a call and ejects the endpoint when it keeps failing
+func (s *EndpointSet) finish(ep *endpoint, err error) {
+	s.mu.RLock()
+	cfg := s.outlier
+	s.mu.RUnlock()
+
+	ep.mu.Lock()
+	ep.inFlight--
+	if !isEndpointFailure(err) {
+		ep.consecutiveFailures = 0
+		// Successful calls slowly shrink the ejection time of a recovered endpoint
+		if ep.ejections > 0 && !time.Now().Before(ep.ejectedUntil) {
+			ep.ejections--
+		}
+		ep.mu.Unlock()
+		return
+	}
+	ep.consecutiveFailures++
+	ep.lastError = err.Error()
+	shouldEject := cfg.ConsecutiveFailures > 0 && ep.consecutiveFailures >= cfg.ConsecutiveFailures
+	ep.mu.Unlock()
+
+	if shouldEject {
+		s.eject(ep, cfg)
+	}
+}
+
+// eject removes an endpoint from picking for a growing amount of time,
+// unless too many endpoints are already ejected
+func (s *EndpointSet) eject(ep *endpoint, cfg OutlierConfig) {
+	s.mu.RLock()
+	now := time.Now()
+	total, ejected := len(s.endpoints), 0
+	for _, other := range s.endpoints {
+		if other != ep && other.ejected(now) {
+			ejected++
+		}
+	}
+	s.mu.RUnlock()
+
+	if total == 0 || (ejected+1)*100 > cfg.MaxEjectionPercent*total {
+		return
+	}
+
+	ep.mu.Lock()
+	defer ep.mu.Unlock()
+	if now.Before(ep.ejectedUntil) {
+		return
+	}
+	ep.ejections++
+	duration := cfg.EjectionTime * time.Duration(ep.ejections)
+	if duration > maxEjectionTime {
+		duration = maxEjectionTime
+	}
+	ep.ejectedUntil = now.Add(duration)
+	ep.consecutiveFailures = 0
+}
+
+// isEndpointFailure reports whether a call error points at an unhealthy endpoint
+// rather than at the request itself (e.g. NotFound or InvalidArgument)
+func isEndpointFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,21 @@
+package backend
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// RequestIDInterceptor forwards the request ID of the HTTP request as
+// x-request-id metadata, so backend logs can be correlated with the gateway
+func RequestIDInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if id := reqctx.RequestIDFrom(ctx); id != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, reqctx.RequestIDMetadataKey, id)
+		}
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
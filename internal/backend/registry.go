@@ -0,0 +1,250 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/attributes"
+	"google.golang.org/grpc/resolver"
+)
+
+// registryScheme is the resolver scheme for endpoints listed in the registry file,
+// e.g. "registry:///user-service"
+const registryScheme = "registry"
+
+// EndpointInfo is the routing information attached to a registry endpoint
+type EndpointInfo struct {
+	Weight   uint32            `json:"weight,omitempty"`   // Relative share of calls, default 1
+	Metadata map[string]string `json:"metadata,omitempty"` // e.g. {"zone": "hcm-1", "version": "1.3.0"}
+}
+
+// Equal lets resolver attributes compare endpoint infos
+func (i EndpointInfo) Equal(o any) bool {
+	other, ok := o.(EndpointInfo)
+	return ok && i.Weight == other.Weight && maps.Equal(i.Metadata, other.Metadata)
+}
+
+// weight returns the effective weight (at least 1)
+func (i EndpointInfo) weight() uint32 {
+	if i.Weight == 0 {
+		return 1
+	}
+	return i.Weight
+}
+
+// matches reports whether the metadata contains every given key/value pair
+func (i EndpointInfo) matches(prefer map[string]string) bool {
+	for key, value := range prefer {
+		if i.Metadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+type endpointInfoKey struct{}
+
+// endpointInfoOf returns the registry info of a resolved address (weight 1 when absent)
+func endpointInfoOf(addr resolver.Address) EndpointInfo {
+	info, _ := addr.BalancerAttributes.Value(endpointInfoKey{}).(EndpointInfo)
+	return info
+}
+
+// registryEndpoint is one endpoint entry of the registry file
+type registryEndpoint struct {
+	Address string `json:"address"`
+	EndpointInfo
+}
+
+// Registry resolves backend endpoints from a local JSON file, or from every *.json
+// file in a directory, and pushes changes to the gRPC connections using it.
+//
+// File format (directory files are merged):
+//
+//	{
+//	  "user-service": [
+//	    {"address": "10.0.0.11:50051", "weight": 2, "metadata": {"zone": "hcm-1"}},
+//	    {"address": "10.0.0.12:50051", "metadata": {"zone": "hcm-2"}}
+//	  ]
+//	}
+type Registry struct {
+	path     string
+	interval time.Duration
+}
+
+// NewRegistry creates a registry reading path (file or directory) every interval
+func NewRegistry(path string, interval time.Duration) *Registry {
+	return &Registry{path: path, interval: interval}
+}
+
+// Scheme returns the resolver scheme handled by the registry
+func (r *Registry) Scheme() string {
+	return registryScheme
+}
+
+// Build starts watching the registry for the service named by the target
+func (r *Registry) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	service := strings.TrimPrefix(target.Endpoint(), "/")
+	if service == "" {
+		return nil, fmt.Errorf("registry target %q has no service name", target.URL.String())
+	}
+
+	rr := &registryResolver{
+		registry: r,
+		service:  service,
+		cc:       cc,
+		resolve:  make(chan struct{}, 1),
+		done:     make(chan struct{}),
+	}
+	rr.update()
+
+	rr.wg.Add(1)
+	go rr.watch()
+	return rr, nil
+}
+
+// load reads every endpoint list from the registry path
+func (r *Registry) load() (map[string][]registryEndpoint, error) {
+	files, err := r.files()
+	if err != nil {
+		return nil, err
+	}
+
+	services := make(map[string][]registryEndpoint)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var entries map[string][]registryEndpoint
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", file, err)
+		}
+		for service, endpoints := range entries {
+			services[service] = append(services[service], endpoints...)
+		}
+	}
+	return services, nil
+}
+
+// files lists the registry files in a stable order
+func (r *Registry) files() ([]string, error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{r.path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(r.path, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// version returns a value that changes whenever a registry file is added, removed or modified
+func (r *Registry) version() string {
+	files, err := r.files()
+	if err != nil {
+		return "error: " + err.Error()
+	}
+
+	var sb strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s:%d:%d;", file, info.Size(), info.ModTime().UnixNano())
+	}
+	return sb.String()
+}
+
+// registryResolver watches the registry for one service
+type registryResolver struct {
+	registry *Registry
+	service  string
+	cc       resolver.ClientConn
+
+	resolve chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+
+	version   string
+	endpoints []registryEndpoint
+}
+
+// watch re-reads the registry when it changes or when gRPC asks to re-resolve
+func (rr *registryResolver) watch() {
+	defer rr.wg.Done()
+
+	ticker := time.NewTicker(rr.registry.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-rr.done:
+			return
+		case <-ticker.C:
+			if rr.registry.version() != rr.version {
+				rr.update()
+			}
+		case <-rr.resolve:
+			rr.update()
+		}
+	}
+}
+
+// update reads the registry and pushes the service endpoints when they changed
+func (rr *registryResolver) update() {
+	rr.version = rr.registry.version()
+
+	services, err := rr.registry.load()
+	if err != nil {
+		rr.cc.ReportError(fmt.Errorf("registry: %w", err))
+		return
+	}
+	endpoints, ok := services[rr.service]
+	if !ok {
+		rr.cc.ReportError(fmt.Errorf("registry: service %q not found in %s", rr.service, rr.registry.path))
+		return
+	}
+	if rr.endpoints != nil && reflect.DeepEqual(endpoints, rr.endpoints) {
+		return
+	}
+	rr.endpoints = endpoints
+
+	addrs := make([]resolver.Address, 0, len(endpoints))
+	for _, ep := range endpoints {
+		addrs = append(addrs, resolver.Address{
+			Addr:               ep.Address,
+			BalancerAttributes: attributes.New(endpointInfoKey{}, ep.EndpointInfo),
+		})
+	}
+	rr.cc.UpdateState(resolver.State{Addresses: addrs})
+}
+
+// ResolveNow asks the watcher to re-read the registry
+func (rr *registryResolver) ResolveNow(resolver.ResolveNowOptions) {
+	select {
+	case rr.resolve <- struct{}{}:
+	default:
+	}
+}
+
+// Close stops watching the registry
+func (rr *registryResolver) Close() {
+	close(rr.done)
+	rr.wg.Wait()
+}
@@ -0,0 +1,58 @@
+package backend
+
+import (
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// staticScheme is the resolver scheme for a fixed, comma-separated endpoint list
+const staticScheme = "static"
+
+// Target converts a backend address setting into a gRPC dial target.
+// Targets with a scheme (e.g. "dns:///user-service:50051") are used as is,
+// plain addresses ("host:port" or "host1:port,host2:port") become a static endpoint list.
+func Target(address string) string {
+	if strings.Contains(address, "://") {
+		return address
+	}
+	return staticScheme + ":///" + address
+}
+
+// staticResolverBuilder resolves "static:///host1:port,host2:port" into its endpoints
+type staticResolverBuilder struct{}
+
+func (staticResolverBuilder) Scheme() string {
+	return staticScheme
+}
+
+func (staticResolverBuilder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	var addrs []resolver.Address
+	for _, addr := range strings.Split(target.Endpoint(), ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			addrs = append(addrs, resolver.Address{Addr: addr})
+		}
+	}
+	if len(addrs) > 1 {
+		// The authority of the target is the whole list, so name each endpoint by its
+		// own host for TLS verification (<PREFIX>_TLS_SERVER_NAME still takes precedence)
+		for i := range addrs {
+			if host, _, err := net.SplitHostPort(addrs[i].Addr); err == nil {
+				addrs[i].ServerName = host
+			}
+		}
+	}
+
+	if err := cc.UpdateState(resolver.State{Addresses: addrs}); err != nil {
+		return nil, err
+	}
+	return staticResolver{}, nil
+}
+
+// staticResolver never changes its endpoints
+type staticResolver struct{}
+
+func (staticResolver) ResolveNow(resolver.ResolveNowOptions) {}
+
+func (staticResolver) Close() {}
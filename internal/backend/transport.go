@@ -0,0 +1,207 @@
+package backend
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/thatlq1812/service-3-gateway/internal/tlsconfig"
+)
+
+// TransportMode selects how the connection to a backend is secured
+type TransportMode string
+
+const (
+	TransportInsecure TransportMode = "insecure" // Plaintext (development only)
+	TransportTLS      TransportMode = "tls"      // Server-authenticated TLS
+	TransportMTLS     TransportMode = "mtls"     // TLS with a gateway client certificate
+)
+
+// TransportConfig describes the transport security of one backend
+type TransportConfig struct {
+	Mode       TransportMode
+	CAFile     string // CA bundle verifying the backend; system roots when empty
+	CertFile   string // Client certificate (mtls only)
+	KeyFile    string // Client private key (mtls only)
+	ServerName string // Overrides the name verified against the backend certificate
+}
+
+// Transport holds the loaded, reloadable credentials of one backend
+type Transport struct {
+	cfg     TransportConfig
+	ca      *tlsconfig.CertPool
+	keyPair *tlsconfig.KeyPair
+}
+
+// NewTransport loads the certificate files referenced by cfg
+func NewTransport(cfg TransportConfig) (*Transport, error) {
+	if cfg.Mode == "" {
+		cfg.Mode = TransportInsecure
+	}
+
+	t := &Transport{cfg: cfg}
+
+	switch cfg.Mode {
+	case TransportInsecure:
+		return t, nil
+	case TransportTLS, TransportMTLS:
+	default:
+		return nil, fmt.Errorf("unknown transport mode %q", cfg.Mode)
+	}
+
+	var err error
+	if cfg.CAFile != "" {
+		if t.ca, err = tlsconfig.LoadCertPool(cfg.CAFile); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.Mode == TransportMTLS {
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, errors.New("mtls requires a client certificate and key")
+		}
+		if t.keyPair, err = tlsconfig.LoadKeyPair(cfg.CertFile, cfg.KeyFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// Mode returns the transport mode
+func (t *Transport) Mode() TransportMode {
+	return t.cfg.Mode
+}
+
+// Credentials returns gRPC transport credentials for dialing the backend.
+// Certificates are resolved on every handshake, so reloads only affect new
+// connections and established ones are never dropped.
+func (t *Transport) Credentials() credentials.TransportCredentials {
+	if t.cfg.Mode == TransportInsecure {
+		return insecure.NewCredentials()
+	}
+
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: t.cfg.ServerName,
+	}
+
+	if t.keyPair != nil {
+		cfg.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return t.keyPair.Certificate(), nil
+		}
+	}
+
+	if t.ca != nil {
+		// RootCAs is fixed once set, so verify against the current pool ourselves,
+		// with a verifier bound to the name of each connection
+		cfg.InsecureSkipVerify = true
+		return &caCredentials{TransportCredentials: credentials.NewTLS(cfg), t: t, cfg: cfg}
+	}
+
+	return credentials.NewTLS(cfg)
+}
+
+// caCredentials are TLS credentials verifying the backend against the reloadable CA pool.
+// ConnectionState.ServerName is empty for IP addresses, so the name to verify is taken
+// from the configured ServerName or the dialed authority of each handshake.
+type caCredentials struct {
+	credentials.TransportCredentials
+	t   *Transport
+	cfg *tls.Config
+}
+
+// ClientHandshake verifies the chain and the name of the backend being dialed
+func (c *caCredentials) ClientHandshake(ctx context.Context, authority string, rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	name := c.cfg.ServerName
+	if name == "" {
+		name = authority
+		if host, _, err := net.SplitHostPort(authority); err == nil {
+			name = host
+		}
+	}
+	if name == "" {
+		return nil, nil, errors.New("no server name to verify the backend certificate against")
+	}
+
+	cfg := c.cfg.Clone()
+	cfg.ServerName = name
+	cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+		return c.t.verifyConnection(cs, name)
+	}
+	return credentials.NewTLS(cfg).ClientHandshake(ctx, authority, rawConn)
+}
+
+// Clone returns a copy of the credentials
+func (c *caCredentials) Clone() credentials.TransportCredentials {
+	cfg := c.cfg.Clone()
+	return &caCredentials{TransportCredentials: credentials.NewTLS(cfg), t: c.t, cfg: cfg}
+}
+
+// OverrideServerName sets the name verified on later handshakes
+func (c *caCredentials) OverrideServerName(name string) error {
+	c.cfg.ServerName = name
+	return nil
+}
+
+// verifyConnection performs standard chain and hostname (or IP address) verification
+// of name using the reloaded CA pool
+func (t *Transport) verifyConnection(cs tls.ConnectionState, name string) error {
+	if name == "" {
+		return errors.New("no server name to verify the backend certificate against")
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return errors.New("backend presented no certificate")
+	}
+
+	opts := x509.VerifyOptions{
+		Roots:         t.ca.Pool(),
+		DNSName:       name,
+		Intermediates: x509.NewCertPool(),
+	}
+	for _, cert := range cs.PeerCertificates[1:] {
+		opts.Intermediates.AddCert(cert)
+	}
+
+	_, err := cs.PeerCertificates[0].Verify(opts)
+	return err
+}
+
+// Reloaders returns the certificate files to watch
+func (t *Transport) Reloaders() []tlsconfig.Reloader {
+	var items []tlsconfig.Reloader
+	if t.ca != nil {
+		items = append(items, t.ca)
+	}
+	if t.keyPair != nil {
+		items = append(items, t.keyPair)
+	}
+	return items
+}
+
+// String describes the transport for startup logs
+func (t *Transport) String() string {
+	switch t.cfg.Mode {
+	case TransportInsecure:
+		return "insecure (plaintext)"
+	default:
+		ca := "system roots"
+		if t.cfg.CAFile != "" {
+			ca = t.cfg.CAFile
+		}
+		desc := fmt.Sprintf("%s (ca=%s", t.cfg.Mode, ca)
+		if t.cfg.Mode == TransportMTLS {
+			desc += ", client_cert=" + t.cfg.CertFile
+		}
+		if t.cfg.ServerName != "" {
+			desc += ", server_name=" + t.cfg.ServerName
+		}
+		return desc + ")"
+	}
+}
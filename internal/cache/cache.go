@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Entry is a cached response
+type Entry struct {
+	Status  int
+	Header  http.Header
+	Body    []byte
+	Stored  time.Time
+	Expires time.Time
+	Tags    []string // Invalidation tags, e.g. "user:7"
+}
+
+// Age returns how long ago the entry was stored
+func (e *Entry) Age(now time.Time) time.Duration {
+	return now.Sub(e.Stored)
+}
+
+// size approximates the memory held by an entry
+func (e *Entry) size(key string) int64 {
+	n := len(key) + len(e.Body)
+	for name, values := range e.Header {
+		n += len(name)
+		for _, v := range values {
+			n += len(v)
+		}
+	}
+	for _, tag := range e.Tags {
+		n += len(tag)
+	}
+	return int64(n)
+}
+
+// Stats are the counters of a cache since it was created
+type Stats struct {
+	Entries       int     `json:"entries"`
+	Bytes         int64   `json:"bytes"`
+	MaxEntries    int     `json:"max_entries"`
+	MaxBytes      int64   `json:"max_bytes"`
+	Hits          uint64  `json:"hits"`
+	Misses        uint64  `json:"misses"`
+	Bypasses      uint64  `json:"bypasses"`      // Lookups skipped, e.g. Cache-Control: no-cache
+	Evictions     uint64  `json:"evictions"`     // Least recently used entries dropped for room
+	Expirations   uint64  `json:"expirations"`   // Entries found past their TTL
+	Invalidations uint64  `json:"invalidations"` // Entries dropped by writes and purges
+	HitRatio      float64 `json:"hit_ratio"`     // Hits / (hits + misses)
+}
+
+// Cache is an in-memory LRU cache of responses bounded by entry count and size.
+// Entries carry tags so that every response depending on an entity can be
+// invalidated at once.
+type Cache struct {
+	mu         sync.Mutex
+	maxEntries int
+	maxBytes   int64
+	lru        *list.List // Front is the most recently used
+	items      map[string]*list.Element
+	tags       map[string]map[string]struct{} // Tag -> keys
+	bytes      int64
+	generation uint64 // Incremented on every invalidation
+	stats      Stats
+}
+
+type item struct {
+	key   string
+	entry *Entry
+	size  int64
+}
+
+// New creates a cache holding at most maxEntries entries and maxBytes bytes (0 for no size limit)
+func New(maxEntries int, maxBytes int64) *Cache {
+	if maxEntries <= 0 {
+		maxEntries = 10000
+	}
+	return &Cache{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		lru:        list.New(),
+		items:      make(map[string]*list.Element),
+		tags:       make(map[string]map[string]struct{}),
+	}
+}
+
+// Get returns the unexpired entry of key and marks it as recently used
+func (c *Cache) Get(key string) (*Entry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	it := el.Value.(*item)
+	if !time.Now().Before(it.entry.Expires) {
+		c.remove(el)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	c.stats.Hits++
+	return it.entry, true
+}
+
+// Bypass counts a request served without looking up the cache
+func (c *Cache) Bypass() {
+	c.mu.Lock()
+	c.stats.Bypasses++
+	c.mu.Unlock()
+}
+
+// Generation returns the invalidation counter, to be passed to Set
+func (c *Cache) Generation() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Set stores an entry unless something was invalidated since generation was read,
+// so a response fetched while a write was in progress is never cached.
+// It reports whether the entry was stored.
+func (c *Cache) Set(key string, entry *Entry, generation uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return false
+	}
+	size := entry.size(key)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return false
+	}
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+
+	c.items[key] = c.lru.PushFront(&item{key: key, entry: entry, size: size})
+	c.bytes += size
+	for _, tag := range entry.Tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+	}
+
+	for c.lru.Len() > c.maxEntries || (c.maxBytes > 0 && c.bytes > c.maxBytes) {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+	return true
+}
+
+// Invalidate drops every entry carrying one of tags and returns how many were dropped
+func (c *Cache) Invalidate(tags ...string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	dropped := 0
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if el, ok := c.items[key]; ok {
+				c.remove(el)
+				dropped++
+			}
+		}
+	}
+	c.stats.Invalidations += uint64(dropped)
+	return dropped
+}
+
+// Purge drops every entry and returns how many were dropped
+func (c *Cache) Purge() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	dropped := len(c.items)
+	c.lru.Init()
+	c.items = make(map[string]*list.Element)
+	c.tags = make(map[string]map[string]struct{})
+	c.bytes = 0
+	c.stats.Invalidations += uint64(dropped)
+	return dropped
+}
+
+// Stats returns the current counters
+func (c *Cache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.items)
+	stats.Bytes = c.bytes
+	stats.MaxEntries = c.maxEntries
+	stats.MaxBytes = c.maxBytes
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(lookups)
+	}
+	return stats
+}
+
+// remove drops an element from the list, the index and its tags
+func (c *Cache) remove(el *list.Element) {
+	it := c.lru.Remove(el).(*item)
+	delete(c.items, it.key)
+	c.bytes -= it.size
+	for _, tag := range it.entry.Tags {
+		if keys, ok := c.tags[tag]; ok {
+			delete(keys, it.key)
+			if len(keys) == 0 {
+				delete(c.tags, tag)
+			}
+		}
+	}
+}
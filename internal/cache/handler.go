@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+)
+
+// Handler serves the statistics of c on GET and purges it on DELETE.
+// DELETE ?tag=user:7 (repeatable) only drops the entries carrying those tags.
+func Handler(c *Cache) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+			json.NewEncoder(w).Encode(c.Stats())
+		case http.MethodDelete:
+			var purged int
+			if tags := r.URL.Query()["tag"]; len(tags) > 0 {
+				purged = c.Invalidate(tags...)
+				slog.Info("Response cache purged", "tags", tags, "entries", purged)
+			} else {
+				purged = c.Purge()
+				slog.Info("Response cache purged", "entries", purged)
+			}
+			json.NewEncoder(w).Encode(map[string]int{"purged": purged})
+		default:
+			w.Header().Set("Allow", "GET, DELETE")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -30,6 +30,7 @@ type Breaker struct {
 	failures        uint32
 	lastFailTime    time.Time
 	lastSuccessTime time.Time
+	listeners       []func(from, to State)
 }
 
 // NewBreaker creates a new circuit breaker
@@ -61,32 +62,47 @@ func (b *Breaker) Execute(ctx context.Context, fn func(context.Context) error) e
 	return nil
 }
 
+// OnStateChange registers fn to be called after every state transition.
+// fn runs outside the breaker lock and must not block.
+func (b *Breaker) OnStateChange(fn func(from, to State)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, fn)
+}
+
 // isOpen checks if circuit breaker is open
 func (b *Breaker) isOpen() bool {
 	b.mu.RLock()
-	defer b.mu.RUnlock()
+	state, lastFailTime := b.state, b.lastFailTime
+	b.mu.RUnlock()
 
-	if b.state == StateClosed {
+	if state == StateClosed {
 		return false
 	}
 
 	// Check if we should transition from Open to Half-Open
-	if b.state == StateOpen && time.Since(b.lastFailTime) > b.resetTimeout {
-		b.mu.RUnlock()
+	if state == StateOpen && time.Since(lastFailTime) > b.resetTimeout {
 		b.mu.Lock()
-		b.state = StateHalfOpen
+		from := b.state
+		if from == StateOpen {
+			b.state = StateHalfOpen
+		}
+		listeners := b.listeners
 		b.mu.Unlock()
-		b.mu.RLock()
+
+		if from == StateOpen {
+			notify(listeners, StateOpen, StateHalfOpen)
+		}
 		return false
 	}
 
-	return b.state == StateOpen
+	return state == StateOpen
 }
 
 // recordFailure records a failed execution
 func (b *Breaker) recordFailure() {
 	b.mu.Lock()
-	defer b.mu.Unlock()
+	from := b.state
 
 	b.failures++
 	b.lastFailTime = time.Now()
@@ -94,19 +110,23 @@ func (b *Breaker) recordFailure() {
 	if b.state == StateHalfOpen {
 		// Failed during half-open, immediately go back to open
 		b.state = StateOpen
-		return
+	} else if b.failures >= b.maxFailures {
+		// Check if we should open the circuit
+		b.state = StateOpen
 	}
 
-	// Check if we should open the circuit
-	if b.failures >= b.maxFailures {
-		b.state = StateOpen
+	to, listeners := b.state, b.listeners
+	b.mu.Unlock()
+
+	if from != to {
+		notify(listeners, from, to)
 	}
 }
 
 // recordSuccess records a successful execution
 func (b *Breaker) recordSuccess() {
 	b.mu.Lock()
-	defer b.mu.Unlock()
+	from := b.state
 
 	b.lastSuccessTime = time.Now()
 
@@ -120,6 +140,20 @@ func (b *Breaker) recordSuccess() {
 	if b.state == StateClosed {
 		b.failures = 0
 	}
+
+	to, listeners := b.state, b.listeners
+	b.mu.Unlock()
+
+	if from != to {
+		notify(listeners, from, to)
+	}
+}
+
+// notify calls the state change listeners
+func notify(listeners []func(from, to State), from, to State) {
+	for _, fn := range listeners {
+		fn(from, to)
+	}
 }
 
 // GetState returns current circuit breaker state
@@ -131,7 +165,11 @@ func (b *Breaker) GetState() State {
 
 // GetStateString returns state as human-readable string
 func (b *Breaker) GetStateString() string {
-	state := b.GetState()
+	return b.GetState().String()
+}
+
+// String returns the state as CLOSED, OPEN or HALF_OPEN
+func (state State) String() string {
 	switch state {
 	case StateClosed:
 		return "CLOSED"
@@ -0,0 +1,182 @@
+package diag
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	"google.golang.org/grpc/channelz/service"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Channel is the channelz view of a client connection or a nested balancer channel
+type Channel struct {
+	ID          int64        `json:"id"`
+	Backend     string       `json:"backend,omitempty"`
+	Target      string       `json:"target"`
+	State       string       `json:"state"`
+	Calls       Calls        `json:"calls"`
+	Channels    []Channel    `json:"channels,omitempty"`
+	Subchannels []Subchannel `json:"subchannels,omitempty"`
+}
+
+// Subchannel is a connection to one backend address
+type Subchannel struct {
+	ID      int64    `json:"id"`
+	Target  string   `json:"target"`
+	State   string   `json:"state"`
+	Calls   Calls    `json:"calls"`
+	Sockets []Socket `json:"sockets,omitempty"`
+}
+
+// Socket is a transport of a subchannel
+type Socket struct {
+	ID                  int64      `json:"id"`
+	Remote              string     `json:"remote,omitempty"`
+	StreamsStarted      int64      `json:"streams_started"`
+	StreamsSucceeded    int64      `json:"streams_succeeded"`
+	StreamsFailed       int64      `json:"streams_failed"`
+	LastMessageSent     *time.Time `json:"last_message_sent,omitempty"`
+	LastMessageReceived *time.Time `json:"last_message_received,omitempty"`
+}
+
+// Calls are the call counters of a channel or subchannel
+type Calls struct {
+	Started       int64      `json:"started"`
+	Succeeded     int64      `json:"succeeded"`
+	Failed        int64      `json:"failed"`
+	LastStartedAt *time.Time `json:"last_started_at,omitempty"`
+}
+
+// serviceCapture captures the channelz service implementation instead of serving it,
+// so channelz data can be read in-process without a gRPC listener
+type serviceCapture struct {
+	server channelzpb.ChannelzServer
+}
+
+func (c *serviceCapture) RegisterService(_ *grpc.ServiceDesc, impl any) {
+	c.server = impl.(channelzpb.ChannelzServer)
+}
+
+// ChannelzHandler serves the gRPC client channels as JSON. backends maps a dial
+// target to its backend name, e.g. "localhost:50051" -> "user-service".
+func ChannelzHandler(backends map[string]string) http.Handler {
+	capture := &serviceCapture{}
+	service.RegisterChannelzServiceToServer(capture)
+	cz := capture.server
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		channels := []Channel{}
+
+		var start int64
+		for {
+			resp, err := cz.GetTopChannels(ctx, &channelzpb.GetTopChannelsRequest{StartChannelId: start})
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			for _, ch := range resp.GetChannel() {
+				channel := convertChannel(ctx, cz, ch)
+				channel.Backend = backends[channel.Target]
+				channels = append(channels, channel)
+				start = ch.GetRef().GetChannelId() + 1
+			}
+			if resp.GetEnd() || len(resp.GetChannel()) == 0 {
+				break
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]any{"channels": channels})
+	})
+}
+
+func convertChannel(ctx context.Context, cz channelzpb.ChannelzServer, ch *channelzpb.Channel) Channel {
+	channel := Channel{
+		ID:     ch.GetRef().GetChannelId(),
+		Target: ch.GetData().GetTarget(),
+		State:  channelState(ch.GetData()),
+		Calls:  channelCalls(ch.GetData()),
+	}
+	for _, ref := range ch.GetChannelRef() {
+		resp, err := cz.GetChannel(ctx, &channelzpb.GetChannelRequest{ChannelId: ref.GetChannelId()})
+		if err == nil {
+			channel.Channels = append(channel.Channels, convertChannel(ctx, cz, resp.GetChannel()))
+		}
+	}
+	for _, ref := range ch.GetSubchannelRef() {
+		resp, err := cz.GetSubchannel(ctx, &channelzpb.GetSubchannelRequest{SubchannelId: ref.GetSubchannelId()})
+		if err == nil {
+			channel.Subchannels = append(channel.Subchannels, convertSubchannel(ctx, cz, resp.GetSubchannel()))
+		}
+	}
+	return channel
+}
+
+func convertSubchannel(ctx context.Context, cz channelzpb.ChannelzServer, sc *channelzpb.Subchannel) Subchannel {
+	subchannel := Subchannel{
+		ID:     sc.GetRef().GetSubchannelId(),
+		Target: sc.GetData().GetTarget(),
+		State:  channelState(sc.GetData()),
+		Calls:  channelCalls(sc.GetData()),
+	}
+	for _, ref := range sc.GetSocketRef() {
+		resp, err := cz.GetSocket(ctx, &channelzpb.GetSocketRequest{SocketId: ref.GetSocketId()})
+		if err != nil {
+			continue
+		}
+		data := resp.GetSocket().GetData()
+		subchannel.Sockets = append(subchannel.Sockets, Socket{
+			ID:                  ref.GetSocketId(),
+			Remote:              socketAddress(resp.GetSocket().GetRemote()),
+			StreamsStarted:      data.GetStreamsStarted(),
+			StreamsSucceeded:    data.GetStreamsSucceeded(),
+			StreamsFailed:       data.GetStreamsFailed(),
+			LastMessageSent:     timestamp(data.GetLastMessageSentTimestamp()),
+			LastMessageReceived: timestamp(data.GetLastMessageReceivedTimestamp()),
+		})
+	}
+	return subchannel
+}
+
+func channelState(data *channelzpb.ChannelData) string {
+	return strings.TrimPrefix(data.GetState().GetState().String(), "STATE_")
+}
+
+func channelCalls(data *channelzpb.ChannelData) Calls {
+	return Calls{
+		Started:       data.GetCallsStarted(),
+		Succeeded:     data.GetCallsSucceeded(),
+		Failed:        data.GetCallsFailed(),
+		LastStartedAt: timestamp(data.GetLastCallStartedTimestamp()),
+	}
+}
+
+// socketAddress renders a TCP address as host:port
+func socketAddress(addr *channelzpb.Address) string {
+	if tcp := addr.GetTcpipAddress(); tcp != nil {
+		return net.JoinHostPort(net.IP(tcp.GetIpAddress()).String(), strconv.Itoa(int(tcp.GetPort())))
+	}
+	if uds := addr.GetUdsAddress(); uds != nil {
+		return "unix:" + uds.GetFilename()
+	}
+	return ""
+}
+
+// timestamp converts a channelz timestamp, nil when the event never happened
+func timestamp(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil || (ts.GetSeconds() == 0 && ts.GetNanos() == 0) {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
@@ -0,0 +1,47 @@
+package diag
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+)
+
+// SecretHeader carries the admin secret, as an alternative to "Authorization: Bearer <secret>"
+const SecretHeader = "X-Admin-Secret"
+
+// Handler serves the debug endpoints under /debug/, all behind the shared admin secret:
+//
+//	/debug/pprof/        net/http/pprof profiles
+//	/debug/channelz      gRPC client channels, subchannels and call counts as JSON
+//	/debug/goroutines    goroutines grouped by stack
+//
+// backends maps gRPC dial targets to backend names for the channelz view.
+func Handler(secret string, backends map[string]string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/channelz", ChannelzHandler(backends))
+	mux.Handle("GET /debug/goroutines", GoroutinesHandler())
+	return RequireSecret(secret, mux)
+}
+
+// RequireSecret rejects requests that do not present secret, compared in constant time
+func RequireSecret(secret string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		presented := r.Header.Get(SecretHeader)
+		if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "bearer") {
+			presented = token
+		}
+
+		if secret == "" || subtle.ConstantTimeCompare([]byte(presented), []byte(secret)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gateway-admin"`)
+			http.Error(w, "admin secret required", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,135 @@
+package diag
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"runtime/pprof"
+	"sort"
+	"strings"
+)
+
+// GoroutineGroup is a set of goroutines with the same stack
+type GoroutineGroup struct {
+	Count  int            `json:"count"`
+	States map[string]int `json:"states"` // e.g. "select": 3, "IO wait": 1
+	IDs    []string       `json:"ids"`
+	Stack  []string       `json:"stack"` // function and file:line, innermost first
+}
+
+var (
+	// goroutineHeader matches "goroutine 42 [select, 5 minutes]:"
+	goroutineHeader = regexp.MustCompile(`^goroutine (\d+) \[([^,\]]+)`)
+	// pcOffset matches the program counter offset of a file:line
+	pcOffset = regexp.MustCompile(` \+0x[0-9a-f]+$`)
+	// creatorID matches the parent goroutine of a "created by" frame
+	creatorID = regexp.MustCompile(` in goroutine \d+$`)
+)
+
+// GroupGoroutines groups the current goroutines by stack, largest group first
+func GroupGoroutines() ([]GoroutineGroup, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 2); err != nil {
+		return nil, err
+	}
+	return parseGoroutines(buf.String()), nil
+}
+
+// parseGoroutines parses a debug=2 goroutine dump
+func parseGoroutines(dump string) []GoroutineGroup {
+	groups := make(map[string]*GoroutineGroup)
+	for _, block := range strings.Split(strings.TrimSpace(dump), "\n\n") {
+		lines := strings.Split(block, "\n")
+		header := goroutineHeader.FindStringSubmatch(lines[0])
+		if header == nil {
+			continue
+		}
+
+		var stack []string
+		for _, line := range lines[1:] {
+			line = strings.TrimSpace(line)
+			line = pcOffset.ReplaceAllString(line, "")
+			line = creatorID.ReplaceAllString(line, "")
+			line = stripArgs(line)
+			stack = append(stack, line)
+		}
+
+		key := strings.Join(stack, "\n")
+		group, ok := groups[key]
+		if !ok {
+			group = &GoroutineGroup{States: make(map[string]int), Stack: stack}
+			groups[key] = group
+		}
+		group.Count++
+		group.States[header[2]]++
+		group.IDs = append(group.IDs, header[1])
+	}
+
+	result := make([]GoroutineGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return strings.Join(result[i].Stack, "\n") < strings.Join(result[j].Stack, "\n")
+	})
+	return result
+}
+
+// stripArgs replaces the argument values of a function frame, which differ between
+// goroutines with the same stack, by "(...)"
+func stripArgs(frame string) string {
+	if !strings.HasSuffix(frame, ")") {
+		return frame
+	}
+	depth := 0
+	for i := len(frame) - 1; i >= 0; i-- {
+		switch frame[i] {
+		case ')':
+			depth++
+		case '(':
+			depth--
+			if depth == 0 {
+				return frame[:i] + "(...)"
+			}
+		}
+	}
+	return frame
+}
+
+// GoroutinesHandler serves the goroutines grouped by stack, as JSON or with ?format=text
+func GoroutinesHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		groups, err := GroupGoroutines()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		total := 0
+		for _, group := range groups {
+			total += group.Count
+		}
+
+		if r.URL.Query().Get("format") == "text" {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			fmt.Fprintf(w, "%d goroutines in %d groups\n", total, len(groups))
+			for _, group := range groups {
+				fmt.Fprintf(w, "\n%d goroutines %v\n", group.Count, group.States)
+				for _, frame := range group.Stack {
+					fmt.Fprintf(w, "  %s\n", frame)
+				}
+			}
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]any{"total": total, "groups": groups})
+	})
+}
@@ -1,18 +1,20 @@
 package handler
 
 import (
-	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"github.com/thatlq1812/service-3-gateway/internal/circuit"
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
 	"github.com/thatlq1812/service-3-gateway/internal/response"
+	"github.com/thatlq1812/service-3-gateway/internal/validation"
 
 	articlepb "github.com/thatlq1812/service-2-article/proto"
 
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
@@ -52,28 +54,32 @@ func extractToken(r *http.Request) string {
 
 // CreateArticleRequest HTTP request body
 type CreateArticleRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
-	UserID  int32  `json:"user_id"`
+	Title   string `json:"title" validate:"trim,required,max=200"`
+	Content string `json:"content" validate:"required,max=100000"`
+	UserID  int32  `json:"user_id" validate:"required,min=1"`
 }
 
 // POST /api/v1/articles
 func (h *ArticleHandler) CreateArticle(w http.ResponseWriter, r *http.Request) {
 	var req CreateArticleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+		response.BadRequest(w, i18n.InvalidRequestBody)
+		return
+	}
+	if errs := validation.Validate(&req); len(errs) > 0 {
+		response.ValidationFailed(w, errs)
 		return
 	}
 
 	// Extract JWT token from Authorization header
 	token := extractToken(r)
 	if token == "" {
-		response.Unauthorized(w, "authorization token required")
+		response.Unauthorized(w, i18n.AuthorizationRequired)
 		return
 	}
 
 	// Forward token in gRPC metadata
-	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	ctx := metadata.AppendToOutgoingContext(r.Context(), "authorization", "Bearer "+token)
 
 	// Call gRPC Article Service
 	resp, err := h.articleClient.CreateArticle(ctx, &articlepb.CreateArticleRequest{
@@ -83,12 +89,7 @@ func (h *ArticleHandler) CreateArticle(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		st, ok := status.FromError(err)
-		if ok {
-			response.GRPCError(w, st.Code(), st.Message())
-		} else {
-			response.Error(w, err)
-		}
+		response.Error(w, err)
 		return
 	}
 
@@ -98,14 +99,7 @@ func (h *ArticleHandler) CreateArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"id":         resp.Data.Article.Id,
-		"title":      resp.Data.Article.Title,
-		"content":    resp.Data.Article.Content,
-		"user_id":    resp.Data.Article.UserId,
-		"created_at": resp.Data.Article.CreatedAt,
-		"updated_at": resp.Data.Article.UpdatedAt,
-	})
+	response.Success(w, newArticleData(resp.Data.Article))
 }
 
 // GET /api/v1/articles/{id}
@@ -113,21 +107,16 @@ func (h *ArticleHandler) GetArticle(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		response.BadRequest(w, "invalid article id")
+		response.BadRequest(w, i18n.InvalidArticleID)
 		return
 	}
 
-	resp, err := h.articleClient.GetArticle(context.Background(), &articlepb.GetArticleRequest{
+	resp, err := h.articleClient.GetArticle(r.Context(), &articlepb.GetArticleRequest{
 		Id: int32(id),
 	})
 
 	if err != nil {
-		st, ok := status.FromError(err)
-		if ok {
-			response.GRPCError(w, st.Code(), st.Message())
-		} else {
-			response.Error(w, err)
-		}
+		response.Error(w, err)
 		return
 	}
 
@@ -137,27 +126,15 @@ func (h *ArticleHandler) GetArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Format ArticleWithUser response
-	articleData := map[string]interface{}{
-		"id":         resp.Data.Article.Article.Id,
-		"title":      resp.Data.Article.Article.Title,
-		"content":    resp.Data.Article.Article.Content,
-		"user_id":    resp.Data.Article.Article.UserId,
-		"created_at": resp.Data.Article.Article.CreatedAt,
-		"updated_at": resp.Data.Article.Article.UpdatedAt,
-	}
-
-	// Include user info if available (null if User Service unavailable)
-	if resp.Data.Article.User != nil {
-		articleData["user"] = map[string]interface{}{
-			"id":         resp.Data.Article.User.Id,
-			"name":       resp.Data.Article.User.Name,
-			"email":      resp.Data.Article.User.Email,
-			"created_at": resp.Data.Article.User.CreatedAt,
-			"updated_at": resp.Data.Article.User.UpdatedAt,
-		}
-	} else {
-		articleData["user"] = nil // Explicitly set null for graceful degradation
+	// The ETag covers the article only, the embedded author is not part of its version
+	if notModified(w, r, newArticleData(resp.Data.Article.Article)) {
+		return
+	}
+
+	// Include user info (null if User Service unavailable, for graceful degradation)
+	articleData := ArticleWithUserData{
+		ArticleData: newArticleData(resp.Data.Article.Article),
+		User:        newArticleUser(resp.Data.Article.User),
 	}
 
 	response.Success(w, articleData)
@@ -165,8 +142,8 @@ func (h *ArticleHandler) GetArticle(w http.ResponseWriter, r *http.Request) {
 
 // UpdateArticleRequest HTTP request body
 type UpdateArticleRequest struct {
-	Title   string `json:"title"`
-	Content string `json:"content"`
+	Title   string `json:"title" validate:"trim,required,max=200"`
+	Content string `json:"content" validate:"required,max=100000"`
 }
 
 // PUT /api/v1/articles/{id}
@@ -174,29 +151,109 @@ func (h *ArticleHandler) UpdateArticle(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		response.BadRequest(w, "invalid article id")
+		response.BadRequest(w, i18n.InvalidArticleID)
 		return
 	}
 
 	var req UpdateArticleRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+		response.BadRequest(w, i18n.InvalidRequestBody)
+		return
+	}
+	if errs := validation.Validate(&req); len(errs) > 0 {
+		response.ValidationFailed(w, errs)
 		return
 	}
 
-	resp, err := h.articleClient.UpdateArticle(context.Background(), &articlepb.UpdateArticleRequest{
+	if !h.checkIfMatch(w, r, int32(id)) {
+		return
+	}
+
+	resp, err := h.articleClient.UpdateArticle(r.Context(), &articlepb.UpdateArticleRequest{
 		Id:      int32(id),
 		Title:   req.Title,
 		Content: req.Content,
 	})
 
 	if err != nil {
-		st, ok := status.FromError(err)
-		if ok {
-			response.GRPCError(w, st.Code(), st.Message())
-		} else {
-			response.Error(w, err)
+		response.Error(w, err)
+		return
+	}
+
+	if resp.Code != "000" {
+		response.CustomError(w, resp.Code, resp.Message)
+		return
+	}
+
+	successWithETag(w, newArticleData(resp.Data.Article))
+}
+
+// PatchArticleRequest HTTP request body, an RFC 7396 merge patch applied over the current
+// title and content: absent members are unchanged, the merged article must stay valid
+type PatchArticleRequest struct {
+	Title   string `json:"title" openapi:"optional"`
+	Content string `json:"content" openapi:"optional"`
+}
+
+// PATCH /api/v1/articles/{id}
+func (h *ArticleHandler) PatchArticle(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.BadRequest(w, i18n.InvalidArticleID)
+		return
+	}
+
+	patch, err := decodeMergePatch(r.Body)
+	if err != nil {
+		response.BadRequest(w, i18n.InvalidMergePatch)
+		return
+	}
+	var req PatchArticleRequest
+	present, errs := patch.decodeInto(&req)
+	if len(errs) > 0 {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	// UpdateArticle replaces both fields: read the current article and merge the patch over it
+	current, ok := h.currentArticle(w, r, int32(id))
+	if !ok {
+		return
+	}
+	if current == nil {
+		ifMatch(w, r, nil)
+		return
+	}
+	if !ifMatch(w, r, *current) {
+		return
+	}
+
+	merged := UpdateArticleRequest{
+		Title:   current.Title,
+		Content: current.Content,
+	}
+	for _, name := range present {
+		switch name {
+		case "title":
+			merged.Title = req.Title
+		case "content":
+			merged.Content = req.Content
 		}
+	}
+	if errs := validation.Validate(&merged); len(errs) > 0 {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	resp, err := h.articleClient.UpdateArticle(r.Context(), &articlepb.UpdateArticleRequest{
+		Id:      int32(id),
+		Title:   merged.Title,
+		Content: merged.Content,
+	})
+
+	if err != nil {
+		response.Error(w, err)
 		return
 	}
 
@@ -205,14 +262,48 @@ func (h *ArticleHandler) UpdateArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"id":         resp.Data.Article.Id,
-		"title":      resp.Data.Article.Title,
-		"content":    resp.Data.Article.Content,
-		"user_id":    resp.Data.Article.UserId,
-		"created_at": resp.Data.Article.CreatedAt,
-		"updated_at": resp.Data.Article.UpdatedAt,
+	successWithETag(w, newArticleData(resp.Data.Article))
+}
+
+// currentArticle reads the article to check or merge into. A missing article is returned
+// as nil when the request has If-Match, so it fails the precondition instead of answering 404.
+func (h *ArticleHandler) currentArticle(w http.ResponseWriter, r *http.Request, id int32) (*ArticleData, bool) {
+	resp, err := h.articleClient.GetArticle(r.Context(), &articlepb.GetArticleRequest{
+		Id: id,
 	})
+
+	notFound := status.Code(err) == codes.NotFound || (err == nil && resp.Code == response.CodeNotFound)
+	if notFound && r.Header.Get("If-Match") != "" {
+		return nil, true
+	}
+	if err != nil {
+		response.Error(w, err)
+		return nil, false
+	}
+
+	if resp.Code != "000" {
+		response.CustomError(w, resp.Code, resp.Message)
+		return nil, false
+	}
+
+	article := newArticleData(resp.Data.Article.Article)
+	return &article, true
+}
+
+// checkIfMatch compares If-Match with the current version of the article, see ifMatch
+func (h *ArticleHandler) checkIfMatch(w http.ResponseWriter, r *http.Request, id int32) bool {
+	if r.Header.Get("If-Match") == "" {
+		return true
+	}
+
+	current, ok := h.currentArticle(w, r, id)
+	if !ok {
+		return false
+	}
+	if current == nil {
+		return ifMatch(w, r, nil)
+	}
+	return ifMatch(w, r, *current)
 }
 
 // DELETE /api/v1/articles/{id}
@@ -220,21 +311,20 @@ func (h *ArticleHandler) DeleteArticle(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		response.BadRequest(w, "invalid article id")
+		response.BadRequest(w, i18n.InvalidArticleID)
 		return
 	}
 
-	resp, err := h.articleClient.DeleteArticle(context.Background(), &articlepb.DeleteArticleRequest{
+	if !h.checkIfMatch(w, r, int32(id)) {
+		return
+	}
+
+	resp, err := h.articleClient.DeleteArticle(r.Context(), &articlepb.DeleteArticleRequest{
 		Id: int32(id),
 	})
 
 	if err != nil {
-		st, ok := status.FromError(err)
-		if ok {
-			response.GRPCError(w, st.Code(), st.Message())
-		} else {
-			response.Error(w, err)
-		}
+		response.Error(w, err)
 		return
 	}
 
@@ -243,9 +333,7 @@ func (h *ArticleHandler) DeleteArticle(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"success": resp.Data.Success,
-	})
+	response.Success(w, SuccessData{Success: resp.Data.Success})
 }
 
 // GET /api/v1/articles?page=1&page_size=10&user_id=1
@@ -262,19 +350,14 @@ func (h *ArticleHandler) ListArticles(w http.ResponseWriter, r *http.Request) {
 
 	userID, _ := strconv.Atoi(r.URL.Query().Get("user_id"))
 
-	resp, err := h.articleClient.ListArticles(context.Background(), &articlepb.ListArticlesRequest{
+	resp, err := h.articleClient.ListArticles(r.Context(), &articlepb.ListArticlesRequest{
 		PageSize:   int32(pageSize),
 		PageNumber: int32(pageNumber),
 		UserId:     int32(userID),
 	})
 
 	if err != nil {
-		st, ok := status.FromError(err)
-		if ok {
-			response.GRPCError(w, st.Code(), st.Message())
-		} else {
-			response.Error(w, err)
-		}
+		response.Error(w, err)
 		return
 	}
 
@@ -283,28 +366,11 @@ func (h *ArticleHandler) ListArticles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	articles := make([]map[string]interface{}, 0, len(resp.Data.Articles))
+	articles := make([]ArticleData, 0, len(resp.Data.Articles))
 	for _, aw := range resp.Data.Articles {
-		articleData := map[string]interface{}{
-			"id":         aw.Article.Id,
-			"title":      aw.Article.Title,
-			"content":    aw.Article.Content,
-			"user_id":    aw.Article.UserId,
-			"created_at": aw.Article.CreatedAt,
-			"updated_at": aw.Article.UpdatedAt,
-		}
-
 		// Include user info if available
-		if aw.User != nil {
-			articleData["user"] = map[string]interface{}{
-				"id":         aw.User.Id,
-				"name":       aw.User.Name,
-				"email":      aw.User.Email,
-				"created_at": aw.User.CreatedAt,
-				"updated_at": aw.User.UpdatedAt,
-			}
-		}
-
+		articleData := newArticleData(aw.Article)
+		articleData.User = newArticleUser(aw.User)
 		articles = append(articles, articleData)
 	}
 
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+)
+
+// entityTag returns the strong ETag of an entity: a hash of its JSON representation,
+// so it changes with updated_at and with every field
+func entityTag(entity any) string {
+	data, _ := json.Marshal(entity)
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-Match or If-None-Match list contains etag, "*" matches any.
+// Weak tags (W/"...") only match with weak comparison, as used by If-None-Match.
+func etagMatches(header, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified sets the ETag of entity and answers 304 when If-None-Match matches it
+func notModified(w http.ResponseWriter, r *http.Request, entity any) bool {
+	etag := entityTag(entity)
+	w.Header().Set("ETag", etag)
+	if inm := r.Header.Get("If-None-Match"); inm != "" && etagMatches(inm, etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// ifMatch checks If-Match against the current entity, nil when it does not exist,
+// writing 412 Precondition Failed on mismatch. It reports whether the request may proceed.
+func ifMatch(w http.ResponseWriter, r *http.Request, current any) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" {
+		return true
+	}
+	if current != nil && etagMatches(header, entityTag(current), false) {
+		return true
+	}
+	response.PreconditionFailed(w, i18n.ETagMismatch)
+	return false
+}
+
+// successWithETag returns the updated entity with its new ETag
+func successWithETag(w http.ResponseWriter, entity any) {
+	w.Header().Set("ETag", entityTag(entity))
+	response.Success(w, entity)
+}
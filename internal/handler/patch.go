@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+)
+
+// mergePatch is an RFC 7396 JSON merge patch: member name -> raw JSON value
+type mergePatch map[string]json.RawMessage
+
+// decodeMergePatch reads a merge patch, which must be a JSON object
+// (a patch replacing the whole resource is not supported)
+func decodeMergePatch(body io.Reader) (mergePatch, error) {
+	var patch mergePatch
+	if err := json.NewDecoder(body).Decode(&patch); err != nil {
+		return nil, err
+	}
+	if patch == nil {
+		return nil, errors.New("merge patch must be a JSON object")
+	}
+	return patch, nil
+}
+
+// decodeInto applies the patch to the string fields of req, a pointer to a struct,
+// and returns the json names of the members present, sorted.
+// Absent members leave the field unchanged, null clears it, a string replaces it;
+// members without a field and values of another type are returned as errors.
+func (p mergePatch) decodeInto(req any) (present []string, errs []response.FieldError) {
+	rv := reflect.ValueOf(req).Elem()
+	fields := make(map[string]reflect.Value)
+	for i := 0; i < rv.NumField(); i++ {
+		name, _, _ := strings.Cut(rv.Type().Field(i).Tag.Get("json"), ",")
+		if rv.Field(i).Kind() == reflect.String && name != "" && name != "-" {
+			fields[name] = rv.Field(i)
+		}
+	}
+
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		field, ok := fields[name]
+		if !ok {
+			errs = append(errs, response.NewFieldError(name, "unknown", i18n.FieldNotPatchable, nil))
+			continue
+		}
+
+		raw := bytes.TrimSpace(p[name])
+		if bytes.Equal(raw, []byte("null")) {
+			field.SetString("")
+			present = append(present, name)
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			errs = append(errs, response.NewFieldError(name, "type", i18n.FieldStringOrNull, nil))
+			continue
+		}
+		field.SetString(value)
+		present = append(present, name)
+	}
+	return present, errs
+}
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	articlepb "github.com/thatlq1812/service-2-article/proto"
+
+	userpb "github.com/thatlq1812/service-1-user/proto"
+)
+
+// UserData is the user returned in APIResponse.Data
+type UserData struct {
+	ID        int32  `json:"id"`
+	Name      string `json:"name"`
+	Email     string `json:"email"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+// ArticleData is the article returned in APIResponse.Data
+type ArticleData struct {
+	ID        int32     `json:"id"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	UserID    int32     `json:"user_id"`
+	CreatedAt string    `json:"created_at"`
+	UpdatedAt string    `json:"updated_at"`
+	User      *UserData `json:"user,omitempty"` // Author, included by list and get only
+}
+
+// ArticleWithUserData is an article whose author is always present, null when the User Service is unavailable
+type ArticleWithUserData struct {
+	ArticleData
+	User *UserData `json:"user" openapi:"nullable"`
+}
+
+// TokenData is the token pair returned by login and refresh
+type TokenData struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// ValidateTokenData is the result of token validation
+type ValidateTokenData struct {
+	Valid  bool   `json:"valid"`
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+// SuccessData is returned by operations without a result entity
+type SuccessData struct {
+	Success bool `json:"success"`
+}
+
+func newUserData(u *userpb.User) UserData {
+	return UserData{
+		ID:        u.Id,
+		Name:      u.Name,
+		Email:     u.Email,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
+
+func newArticleData(a *articlepb.Article) ArticleData {
+	return ArticleData{
+		ID:        a.Id,
+		Title:     a.Title,
+		Content:   a.Content,
+		UserID:    a.UserId,
+		CreatedAt: a.CreatedAt,
+		UpdatedAt: a.UpdatedAt,
+	}
+}
+
+// newArticleUser converts the author embedded by the Article Service, nil if absent
+func newArticleUser(u *articlepb.User) *UserData {
+	if u == nil {
+		return nil
+	}
+	return &UserData{
+		ID:        u.Id,
+		Name:      u.Name,
+		Email:     u.Email,
+		CreatedAt: u.CreatedAt,
+		UpdatedAt: u.UpdatedAt,
+	}
+}
@@ -8,11 +8,15 @@ import (
 	"time"
 
 	"github.com/thatlq1812/service-3-gateway/internal/circuit"
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
 	"github.com/thatlq1812/service-3-gateway/internal/response"
+	"github.com/thatlq1812/service-3-gateway/internal/validation"
 
 	userpb "github.com/thatlq1812/service-1-user/proto"
 
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type UserHandler struct {
@@ -36,9 +40,9 @@ func NewUserHandlerWithCircuit(userClient userpb.UserServiceClient, breaker *cir
 
 // CreateUserRequest HTTP request body
 type CreateUserRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name     string `json:"name" validate:"trim,required,max=100"`
+	Email    string `json:"email" openapi:"format=email" validate:"trim,required,max=254,email"`
+	Password string `json:"password" validate:"required,password"`
 }
 
 // POST /api/v1/users
@@ -46,7 +50,11 @@ type CreateUserRequest struct {
 func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	var req CreateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+		response.BadRequest(w, i18n.InvalidRequestBody)
+		return
+	}
+	if errs := validation.Validate(&req); len(errs) > 0 {
+		response.ValidationFailed(w, errs)
 		return
 	}
 
@@ -76,7 +84,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 
 		// Circuit breaker is open
 		if err == circuit.ErrCircuitOpen {
-			response.ServiceUnavailable(w, "user service temporarily unavailable")
+			response.ServiceUnavailable(w, i18n.UserServiceUnavailable)
 			return
 		}
 	} else {
@@ -99,13 +107,7 @@ func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Format response theo mentor yêu cầu
-	response.Success(w, map[string]interface{}{
-		"id":         resp.Data.User.Id,
-		"name":       resp.Data.User.Name,
-		"email":      resp.Data.User.Email,
-		"created_at": resp.Data.User.CreatedAt,
-		"updated_at": resp.Data.User.UpdatedAt,
-	})
+	response.Success(w, newUserData(resp.Data.User))
 }
 
 // GET /api/v1/users/{id}
@@ -113,11 +115,11 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		response.BadRequest(w, "invalid user id")
+		response.BadRequest(w, i18n.InvalidUserID)
 		return
 	}
 
-	resp, err := h.userClient.GetUser(context.Background(), &userpb.GetUserRequest{
+	resp, err := h.userClient.GetUser(r.Context(), &userpb.GetUserRequest{
 		Id: int32(id),
 	})
 
@@ -131,20 +133,18 @@ func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"id":         resp.Data.User.Id,
-		"name":       resp.Data.User.Name,
-		"email":      resp.Data.User.Email,
-		"created_at": resp.Data.User.CreatedAt,
-		"updated_at": resp.Data.User.UpdatedAt,
-	})
+	user := newUserData(resp.Data.User)
+	if notModified(w, r, user) {
+		return
+	}
+	response.Success(w, user)
 }
 
 // UpdateUserRequest HTTP request body
 type UpdateUserRequest struct {
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password,omitempty"`
+	Name     string `json:"name" openapi:"optional" validate:"trim,omitempty,max=100"` // Empty keeps the current value
+	Email    string `json:"email" openapi:"optional" validate:"trim,omitempty,max=254,email"`
+	Password string `json:"password,omitempty" validate:"omitempty,password"`
 }
 
 // PUT /api/v1/users/{id}
@@ -152,13 +152,21 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		response.BadRequest(w, "invalid user id")
+		response.BadRequest(w, i18n.InvalidUserID)
 		return
 	}
 
 	var req UpdateUserRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+		response.BadRequest(w, i18n.InvalidRequestBody)
+		return
+	}
+	if errs := validation.Validate(&req); len(errs) > 0 {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	if !h.checkIfMatch(w, r, int32(id)) {
 		return
 	}
 
@@ -176,7 +184,71 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		grpcReq.Password = &req.Password
 	}
 
-	resp, err := h.userClient.UpdateUser(context.Background(), grpcReq)
+	resp, err := h.userClient.UpdateUser(r.Context(), grpcReq)
+
+	if err != nil {
+		response.Error(w, err)
+		return
+	}
+
+	if resp.Code != "000" {
+		response.CustomError(w, resp.Code, resp.Message)
+		return
+	}
+
+	successWithETag(w, newUserData(resp.Data.User))
+}
+
+// PatchUserRequest HTTP request body, an RFC 7396 merge patch: absent members are unchanged,
+// null or "" clears the name, email and password cannot be removed
+type PatchUserRequest struct {
+	Name     string `json:"name" openapi:"optional,nullable" validate:"trim,max=100"`
+	Email    string `json:"email" openapi:"optional,format=email" validate:"trim,required,max=254,email"`
+	Password string `json:"password" openapi:"optional" validate:"required,password"`
+}
+
+// PATCH /api/v1/users/{id}
+func (h *UserHandler) PatchUser(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		response.BadRequest(w, i18n.InvalidUserID)
+		return
+	}
+
+	patch, err := decodeMergePatch(r.Body)
+	if err != nil {
+		response.BadRequest(w, i18n.InvalidMergePatch)
+		return
+	}
+	var req PatchUserRequest
+	present, errs := patch.decodeInto(&req)
+	errs = append(errs, validation.ValidateFields(&req, present...)...)
+	if len(errs) > 0 {
+		response.ValidationFailed(w, errs)
+		return
+	}
+
+	if !h.checkIfMatch(w, r, int32(id)) {
+		return
+	}
+
+	// Only the members present in the patch are set, an empty name clears it
+	grpcReq := &userpb.UpdateUserRequest{
+		Id: int32(id),
+	}
+	for _, name := range present {
+		switch name {
+		case "name":
+			grpcReq.Name = &req.Name
+		case "email":
+			grpcReq.Email = &req.Email
+		case "password":
+			grpcReq.Password = &req.Password
+		}
+	}
+
+	resp, err := h.userClient.UpdateUser(r.Context(), grpcReq)
 
 	if err != nil {
 		response.Error(w, err)
@@ -188,13 +260,33 @@ func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"id":         resp.Data.User.Id,
-		"name":       resp.Data.User.Name,
-		"email":      resp.Data.User.Email,
-		"created_at": resp.Data.User.CreatedAt,
-		"updated_at": resp.Data.User.UpdatedAt,
+	successWithETag(w, newUserData(resp.Data.User))
+}
+
+// checkIfMatch compares If-Match with the current version of the user, see ifMatch
+func (h *UserHandler) checkIfMatch(w http.ResponseWriter, r *http.Request, id int32) bool {
+	if r.Header.Get("If-Match") == "" {
+		return true
+	}
+
+	resp, err := h.userClient.GetUser(r.Context(), &userpb.GetUserRequest{
+		Id: id,
 	})
+
+	if status.Code(err) == codes.NotFound || (err == nil && resp.Code == response.CodeNotFound) {
+		return ifMatch(w, r, nil)
+	}
+	if err != nil {
+		response.Error(w, err)
+		return false
+	}
+
+	if resp.Code != "000" {
+		response.CustomError(w, resp.Code, resp.Message)
+		return false
+	}
+
+	return ifMatch(w, r, newUserData(resp.Data.User))
 }
 
 // DELETE /api/v1/users/{id}
@@ -202,11 +294,15 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
 	if err != nil {
-		response.BadRequest(w, "invalid user id")
+		response.BadRequest(w, i18n.InvalidUserID)
+		return
+	}
+
+	if !h.checkIfMatch(w, r, int32(id)) {
 		return
 	}
 
-	resp, err := h.userClient.DeleteUser(context.Background(), &userpb.DeleteUserRequest{
+	resp, err := h.userClient.DeleteUser(r.Context(), &userpb.DeleteUserRequest{
 		Id: int32(id),
 	})
 
@@ -220,9 +316,7 @@ func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"success": resp.Data.Success,
-	})
+	response.Success(w, SuccessData{Success: resp.Data.Success})
 }
 
 // GET /api/v1/users?page=1&page_size=10
@@ -237,7 +331,7 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		pageSize = 10
 	}
 
-	resp, err := h.userClient.ListUsers(context.Background(), &userpb.ListUsersRequest{
+	resp, err := h.userClient.ListUsers(r.Context(), &userpb.ListUsersRequest{
 		Page:     int32(page),
 		PageSize: int32(pageSize),
 	})
@@ -252,15 +346,9 @@ func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	users := make([]map[string]interface{}, 0, len(resp.Data.Users))
+	users := make([]UserData, 0, len(resp.Data.Users))
 	for _, u := range resp.Data.Users {
-		users = append(users, map[string]interface{}{
-			"id":         u.Id,
-			"name":       u.Name,
-			"email":      u.Email,
-			"created_at": u.CreatedAt,
-			"updated_at": u.UpdatedAt,
-		})
+		users = append(users, newUserData(u))
 	}
 
 	// Format list response theo mentor: {"code":"0", "message":"success", "data":{"items":[...], "total":...}}
@@ -277,11 +365,11 @@ type LoginRequest struct {
 func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+		response.BadRequest(w, i18n.InvalidRequestBody)
 		return
 	}
 
-	resp, err := h.userClient.Login(context.Background(), &userpb.LoginRequest{
+	resp, err := h.userClient.Login(r.Context(), &userpb.LoginRequest{
 		Email:    req.Email,
 		Password: req.Password,
 	})
@@ -296,9 +384,9 @@ func (h *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"access_token":  resp.Data.AccessToken,
-		"refresh_token": resp.Data.RefreshToken,
+	response.Success(w, TokenData{
+		AccessToken:  resp.Data.AccessToken,
+		RefreshToken: resp.Data.RefreshToken,
 	})
 }
 
@@ -311,11 +399,11 @@ type ValidateTokenRequest struct {
 func (h *UserHandler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 	var req ValidateTokenRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+		response.BadRequest(w, i18n.InvalidRequestBody)
 		return
 	}
 
-	resp, err := h.userClient.ValidateToken(context.Background(), &userpb.ValidateTokenRequest{
+	resp, err := h.userClient.ValidateToken(r.Context(), &userpb.ValidateTokenRequest{
 		Token: req.Token,
 	})
 
@@ -329,10 +417,10 @@ func (h *UserHandler) ValidateToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"valid":   resp.Data.Valid,
-		"user_id": resp.Data.UserId,
-		"email":   resp.Data.Email,
+	response.Success(w, ValidateTokenData{
+		Valid:  resp.Data.Valid,
+		UserID: resp.Data.UserId,
+		Email:  resp.Data.Email,
 	})
 }
 
@@ -345,11 +433,11 @@ type RefreshTokenRequest struct {
 func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 	var req RefreshTokenRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+		response.BadRequest(w, i18n.InvalidRequestBody)
 		return
 	}
 
-	resp, err := h.userClient.RefreshToken(context.Background(), &userpb.RefreshTokenRequest{
+	resp, err := h.userClient.RefreshToken(r.Context(), &userpb.RefreshTokenRequest{
 		RefreshToken: req.RefreshToken,
 	})
 
@@ -363,16 +451,16 @@ func (h *UserHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"access_token":  resp.Data.AccessToken,
-		"refresh_token": resp.Data.RefreshToken,
+	response.Success(w, TokenData{
+		AccessToken:  resp.Data.AccessToken,
+		RefreshToken: resp.Data.RefreshToken,
 	})
 }
 
 // LogoutRequest HTTP request body
 type LogoutRequest struct {
-	Token        string `json:"token"`         // Access token (required)
-	RefreshToken string `json:"refresh_token"` // Refresh token (optional but recommended)
+	Token        string `json:"token"`                            // Access token (required)
+	RefreshToken string `json:"refresh_token" openapi:"optional"` // Refresh token (optional but recommended)
 }
 
 // POST /api/v1/auth/logout
@@ -380,11 +468,11 @@ type LogoutRequest struct {
 func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
 	var req LogoutRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		response.BadRequest(w, "invalid request body")
+		response.BadRequest(w, i18n.InvalidRequestBody)
 		return
 	}
 
-	resp, err := h.userClient.Logout(context.Background(), &userpb.LogoutRequest{
+	resp, err := h.userClient.Logout(r.Context(), &userpb.LogoutRequest{
 		Token:        req.Token,
 		RefreshToken: req.RefreshToken,
 	})
@@ -399,7 +487,5 @@ func (h *UserHandler) Logout(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response.Success(w, map[string]interface{}{
-		"success": resp.Data.Success,
-	})
+	response.Success(w, SuccessData{Success: resp.Data.Success})
 }
@@ -0,0 +1,230 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/thatlq1812/service-3-gateway/internal/circuit"
+)
+
+// Health statuses reported for a backend
+const (
+	StatusServing    = "SERVING"
+	StatusNotServing = "NOT_SERVING"
+	StatusUnknown    = "UNKNOWN"
+	// StatusNoHealthService means the backend does not implement grpc.health.v1,
+	// so only the connection state is known
+	StatusNoHealthService = "NO_HEALTH_SERVICE"
+)
+
+// Backend is a backend service checked with the gRPC health protocol
+type Backend struct {
+	Name     string           // e.g. "user-service"
+	Conn     *grpc.ClientConn // Connection the checks are sent on
+	Service  string           // Service name sent in HealthCheckRequest, "" for the whole server
+	Breaker  *circuit.Breaker // Optional, reported and used for readiness
+	Critical bool             // Readiness fails when a critical backend is unhealthy
+}
+
+// Result is the outcome of one health check
+type Result struct {
+	Status    string        `json:"status"`
+	Healthy   bool          `json:"healthy"`
+	Latency   time.Duration `json:"-"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// BackendStatus is the detailed health of one backend
+type BackendStatus struct {
+	Name            string    `json:"name"`
+	Critical        bool      `json:"critical"`
+	Healthy         bool      `json:"healthy"`
+	Connectivity    string    `json:"connectivity"`
+	Health          string    `json:"health"`
+	LatencyMs       float64   `json:"latency_ms"`
+	CheckedAt       time.Time `json:"checked_at"`
+	Error           string    `json:"error,omitempty"`
+	BreakerState    string    `json:"breaker_state,omitempty"`
+	BreakerFailures uint32    `json:"breaker_failures"`
+}
+
+// Monitor checks every backend periodically and keeps the last result of each
+type Monitor struct {
+	backends []Backend
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	results   map[string]Result
+	onResult  []func(backend string, result Result)
+	lastCheck time.Time
+
+	checking sync.Mutex // Serializes Refresh, so concurrent callers share one check
+}
+
+// NewMonitor creates a monitor; timeout bounds every Health/Check call
+func NewMonitor(timeout time.Duration, backends ...Backend) *Monitor {
+	return &Monitor{
+		backends: backends,
+		timeout:  timeout,
+		results:  make(map[string]Result),
+	}
+}
+
+// OnResult registers a callback called after every check of every backend
+func (m *Monitor) OnResult(fn func(backend string, result Result)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onResult = append(m.onResult, fn)
+}
+
+// Run checks all backends every interval until ctx is done
+func (m *Monitor) Run(ctx context.Context, interval time.Duration) {
+	m.CheckAll(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.CheckAll(ctx)
+		}
+	}
+}
+
+// CheckAll checks every backend concurrently and returns their detailed status
+func (m *Monitor) CheckAll(ctx context.Context) []BackendStatus {
+	var wg sync.WaitGroup
+	for _, b := range m.backends {
+		wg.Add(1)
+		go func(b Backend) {
+			defer wg.Done()
+			m.record(b.Name, m.check(ctx, b))
+		}(b)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	m.lastCheck = time.Now()
+	m.mu.Unlock()
+	return m.Status()
+}
+
+// Refresh checks every backend unless the last checks are younger than maxAge,
+// in which case their results are returned. Concurrent callers wait for one check
+// instead of each starting their own, and a caller going away does not cancel it.
+func (m *Monitor) Refresh(ctx context.Context, maxAge time.Duration) []BackendStatus {
+	m.checking.Lock()
+	defer m.checking.Unlock()
+
+	m.mu.RLock()
+	fresh := time.Since(m.lastCheck) < maxAge
+	m.mu.RUnlock()
+	if fresh {
+		return m.Status()
+	}
+	return m.CheckAll(context.WithoutCancel(ctx))
+}
+
+// check calls grpc.health.v1.Health/Check on a backend
+func (m *Monitor) check(ctx context.Context, b Backend) Result {
+	ctx, cancel := context.WithTimeout(ctx, m.timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp, err := healthpb.NewHealthClient(b.Conn).Check(ctx, &healthpb.HealthCheckRequest{Service: b.Service})
+	result := Result{Latency: time.Since(start), CheckedAt: time.Now()}
+
+	switch {
+	case err == nil:
+		result.Status = resp.GetStatus().String()
+		result.Healthy = resp.GetStatus() == healthpb.HealthCheckResponse_SERVING
+	case status.Code(err) == codes.Unimplemented:
+		// Backend without a health service: a ready connection is the best signal available
+		result.Status = StatusNoHealthService
+		result.Healthy = b.Conn.GetState() == connectivity.Ready
+	default:
+		result.Status = StatusUnknown
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (m *Monitor) record(backend string, result Result) {
+	m.mu.Lock()
+	m.results[backend] = result
+	callbacks := m.onResult
+	m.mu.Unlock()
+
+	for _, fn := range callbacks {
+		fn(backend, result)
+	}
+}
+
+// Status returns the detailed status of every backend from the last checks
+func (m *Monitor) Status() []BackendStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]BackendStatus, 0, len(m.backends))
+	for _, b := range m.backends {
+		result, checked := m.results[b.Name]
+		if !checked {
+			result = Result{Status: StatusUnknown, Error: "not checked yet"}
+		}
+
+		st := BackendStatus{
+			Name:         b.Name,
+			Critical:     b.Critical,
+			Healthy:      result.Healthy,
+			Connectivity: b.Conn.GetState().String(),
+			Health:       result.Status,
+			LatencyMs:    float64(result.Latency.Microseconds()) / 1000,
+			CheckedAt:    result.CheckedAt,
+			Error:        result.Error,
+		}
+		if b.Breaker != nil {
+			st.BreakerState = b.Breaker.GetStateString()
+			st.BreakerFailures = b.Breaker.GetFailures()
+			// An open breaker rejects every call, whatever the health service says
+			if b.Breaker.GetState() == circuit.StateOpen {
+				st.Healthy = false
+			}
+		}
+		statuses = append(statuses, st)
+	}
+	return statuses
+}
+
+// Ready reports whether every critical backend is healthy, from the last checks
+func Ready(statuses []BackendStatus) bool {
+	for _, st := range statuses {
+		if st.Critical && !st.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Overall summarizes statuses: "healthy", "degraded" (only non-critical backends
+// are unhealthy) or "unhealthy"
+func Overall(statuses []BackendStatus) string {
+	if !Ready(statuses) {
+		return "unhealthy"
+	}
+	for _, st := range statuses {
+		if !st.Healthy {
+			return "degraded"
+		}
+	}
+	return "healthy"
+}
@@ -0,0 +1,251 @@
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Event kinds
+const (
+	KindConnectivity = "connectivity" // grpc.ClientConn state change, e.g. READY -> TRANSIENT_FAILURE
+	KindBreaker      = "breaker"      // Circuit breaker transition, e.g. CLOSED -> OPEN
+	KindHealth       = "health"       // Health check result change, e.g. SERVING -> NOT_SERVING
+)
+
+// Event is one change in the availability of a backend
+type Event struct {
+	Time      time.Time `json:"time"`
+	Backend   string    `json:"backend"`
+	Kind      string    `json:"kind"`
+	From      string    `json:"from,omitempty"`
+	To        string    `json:"to"`
+	Detail    string    `json:"detail,omitempty"`
+	Available bool      `json:"available"` // Backend availability after the event
+}
+
+// maxTransitions bounds the availability transitions kept per backend for uptime
+const maxTransitions = 10000
+
+// Timeline is a bounded, in-memory timeline of backend events, optionally
+// persisted to a JSON-lines file so it survives restarts
+type Timeline struct {
+	mu       sync.RWMutex
+	capacity int
+	events   []Event // Ring buffer, oldest first once rotated
+	start    int
+	backends map[string]*availability
+
+	path    string
+	file    *os.File
+	written int // Lines appended since the file was last compacted
+}
+
+// New creates a timeline keeping the last capacity events. When path is not empty,
+// events are loaded from and appended to that file.
+func New(capacity int, path string) (*Timeline, error) {
+	if capacity <= 0 {
+		capacity = 1000
+	}
+	t := &Timeline{
+		capacity: capacity,
+		backends: make(map[string]*availability),
+		path:     path,
+	}
+	if path == "" {
+		return t, nil
+	}
+
+	if err := t.load(); err != nil {
+		return nil, err
+	}
+	if err := t.compact(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Close closes the persistence file
+func (t *Timeline) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.file == nil {
+		return nil
+	}
+	err := t.file.Close()
+	t.file = nil
+	return err
+}
+
+// Record adds an event for backend when the value of that kind changed,
+// e.g. Record("user-service", KindBreaker, "OPEN", ""). Repeated values are ignored.
+func (t *Timeline) Record(backend, kind, to, detail string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	av := t.availability(backend)
+	from, seen := av.values[kind]
+	if seen && from == to {
+		return
+	}
+
+	event := Event{
+		Time:    time.Now().UTC(),
+		Backend: backend,
+		Kind:    kind,
+		From:    from,
+		To:      to,
+		Detail:  detail,
+	}
+	event.Available = av.apply(event)
+	t.append(event)
+	t.persist(event)
+}
+
+// Events returns the events of backend (all backends when empty) since the given time, oldest first
+func (t *Timeline) Events(backend string, since time.Time) []Event {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	events := make([]Event, 0, len(t.events))
+	for i := range t.events {
+		event := t.events[(t.start+i)%len(t.events)]
+		if (backend == "" || event.Backend == backend) && !event.Time.Before(since) {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// Backends returns the names of every backend with recorded events
+func (t *Timeline) Backends() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	names := make([]string, 0, len(t.backends))
+	for name := range t.backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Uptime returns the share of time (0..100) backend was available during the
+// last window. Only the time since the backend was first seen is counted.
+// ok is false when nothing is known about the backend in that window.
+func (t *Timeline) Uptime(backend string, window time.Duration) (percent float64, ok bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	av, exists := t.backends[backend]
+	if !exists {
+		return 0, false
+	}
+	return av.uptime(time.Now(), window)
+}
+
+// append adds an event to the ring buffer
+func (t *Timeline) append(event Event) {
+	if len(t.events) < t.capacity {
+		t.events = append(t.events, event)
+		return
+	}
+	t.events[t.start] = event
+	t.start = (t.start + 1) % len(t.events)
+}
+
+func (t *Timeline) availability(backend string) *availability {
+	av, ok := t.backends[backend]
+	if !ok {
+		av = newAvailability()
+		t.backends[backend] = av
+	}
+	return av
+}
+
+// load replays the persisted events
+func (t *Timeline) load() error {
+	f, err := os.Open(t.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open history file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	skipped := 0
+	for scanner.Scan() {
+		var event Event
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Backend == "" {
+			skipped++
+			continue
+		}
+		t.availability(event.Backend).apply(event)
+		t.append(event)
+	}
+	if skipped > 0 {
+		slog.Warn("Skipped unreadable history lines", "path", t.path, "lines", skipped)
+	}
+	return scanner.Err()
+}
+
+// compact rewrites the file with the events in memory and reopens it for appending
+func (t *Timeline) compact() error {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+
+	tmp := t.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("compact history file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for i := range t.events {
+		enc.Encode(t.events[(t.start+i)%len(t.events)])
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("compact history file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("compact history file: %w", err)
+	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		return fmt.Errorf("compact history file: %w", err)
+	}
+
+	t.file, err = os.OpenFile(t.path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open history file: %w", err)
+	}
+	t.written = len(t.events)
+	return nil
+}
+
+// persist appends an event to the file, compacting it once it holds twice the capacity
+func (t *Timeline) persist(event Event) {
+	if t.path == "" {
+		return
+	}
+
+	var err error
+	if t.written >= 2*t.capacity {
+		err = t.compact()
+	} else if t.file != nil {
+		err = json.NewEncoder(t.file).Encode(event)
+		t.written++
+	}
+	if err != nil {
+		slog.Warn("Failed to persist history event", "path", t.path, "error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package history
+
+import "time"
+
+// Connectivity states counted as available (IDLE only means no recent calls)
+var availableStates = map[string]bool{"READY": true, "IDLE": true}
+
+// availability derives whether a backend is available from its latest values:
+// a usable connection, a health check that is not failing and a breaker that is not open
+type availability struct {
+	values      map[string]string // Latest value per event kind
+	available   bool
+	firstSeen   time.Time
+	transitions []transition // Availability changes, oldest first
+}
+
+type transition struct {
+	at        time.Time
+	available bool
+}
+
+func newAvailability() *availability {
+	return &availability{values: make(map[string]string)}
+}
+
+// apply records an event and returns the resulting availability
+func (av *availability) apply(event Event) bool {
+	av.values[event.Kind] = event.To
+
+	available := true
+	if state, ok := av.values[KindConnectivity]; ok && !availableStates[state] {
+		available = false
+	}
+	if health, ok := av.values[KindHealth]; ok && health != "SERVING" && health != "NO_HEALTH_SERVICE" {
+		available = false
+	}
+	if av.values[KindBreaker] == "OPEN" {
+		available = false
+	}
+
+	if av.firstSeen.IsZero() {
+		av.firstSeen = event.Time
+		av.transitions = append(av.transitions, transition{at: event.Time, available: available})
+	} else if available != av.available {
+		av.transitions = append(av.transitions, transition{at: event.Time, available: available})
+		if len(av.transitions) > maxTransitions {
+			av.transitions = av.transitions[len(av.transitions)-maxTransitions:]
+		}
+	}
+	av.available = available
+	return available
+}
+
+// uptime integrates the available time over [now-window, now]
+func (av *availability) uptime(now time.Time, window time.Duration) (float64, bool) {
+	if len(av.transitions) == 0 {
+		return 0, false
+	}
+
+	start := now.Add(-window)
+	if first := av.transitions[0].at; start.Before(first) {
+		start = first
+	}
+	total := now.Sub(start)
+	if total <= 0 {
+		return 0, false
+	}
+
+	var up time.Duration
+	for i, tr := range av.transitions {
+		end := now
+		if i+1 < len(av.transitions) {
+			end = av.transitions[i+1].at
+		}
+		if !tr.available || !end.After(start) {
+			continue
+		}
+		from := tr.at
+		if from.Before(start) {
+			from = start
+		}
+		up += end.Sub(from)
+	}
+	return 100 * float64(up) / float64(total), true
+}
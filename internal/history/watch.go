@@ -0,0 +1,71 @@
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// WatchConnectivity records every state change of conn until ctx is done
+func (t *Timeline) WatchConnectivity(ctx context.Context, backend string, conn *grpc.ClientConn) {
+	for {
+		state := conn.GetState()
+		t.Record(backend, KindConnectivity, state.String(), "")
+		if !conn.WaitForStateChange(ctx, state) {
+			return
+		}
+	}
+}
+
+// UptimeWindows are the rolling windows reported by Handler
+var UptimeWindows = []struct {
+	Name     string
+	Duration time.Duration
+}{
+	{"1h", time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// Handler serves the timeline and rolling uptime per backend.
+// Query parameters: backend (default all) and since, a duration such as "12h" (default all).
+func Handler(t *Timeline) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		var since time.Time
+		if value := r.URL.Query().Get("since"); value != "" {
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "since must be a positive duration, e.g. 12h"})
+				return
+			}
+			since = time.Now().Add(-d)
+		}
+		backend := r.URL.Query().Get("backend")
+
+		uptime := make(map[string]map[string]float64)
+		for _, name := range t.Backends() {
+			if backend != "" && name != backend {
+				continue
+			}
+			windows := make(map[string]float64)
+			for _, window := range UptimeWindows {
+				if percent, ok := t.Uptime(name, window.Duration); ok {
+					windows[window.Name] = math.Round(percent*1000) / 1000
+				}
+			}
+			uptime[name] = windows
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"uptime": uptime,
+			"events": t.Events(backend, since),
+		})
+	})
+}
@@ -0,0 +1,195 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Locale files are JSON objects of message key -> text, named after their language tag (vi.json, pt-br.json)
+//
+//go:embed locales/*.json
+var embedded embed.FS
+
+// DefaultLanguage is the fallback when no accepted language has a catalog
+const DefaultLanguage = "en"
+
+// Catalog holds the gateway messages of every language
+type Catalog struct {
+	fallback string
+	messages map[string]map[string]string // language -> key -> text
+}
+
+// New returns the catalog of the embedded locale files, plus the locale files of dirs
+// which add languages or replace the messages they define
+func New(fallback string, dirs ...string) (*Catalog, error) {
+	c := &Catalog{fallback: strings.ToLower(fallback), messages: make(map[string]map[string]string)}
+	if err := c.load(embedded, "locales"); err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if err := c.load(os.DirFS(dir), "."); err != nil {
+			return nil, err
+		}
+	}
+	if _, ok := c.messages[c.fallback]; !ok {
+		return nil, fmt.Errorf("i18n: no locale file for fallback language %q", fallback)
+	}
+	return c, nil
+}
+
+func (c *Catalog) load(fsys fs.FS, dir string) error {
+	files, err := fs.Glob(fsys, path.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("i18n: %w", err)
+	}
+	for _, file := range files {
+		data, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return fmt.Errorf("i18n: %w", err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("i18n: %s: %w", file, err)
+		}
+
+		lang := strings.ToLower(strings.TrimSuffix(path.Base(file), ".json"))
+		if c.messages[lang] == nil {
+			c.messages[lang] = make(map[string]string)
+		}
+		for key, text := range messages {
+			c.messages[lang][key] = text
+		}
+	}
+	return nil
+}
+
+// Languages returns the languages of the catalog, sorted
+func (c *Catalog) Languages() []string {
+	langs := make([]string, 0, len(c.messages))
+	for lang := range c.messages {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Missing returns the keys of the fallback language a language has no text for
+func (c *Catalog) Missing(lang string) []string {
+	var missing []string
+	for key := range c.messages[c.fallback] {
+		if _, ok := c.messages[lang][key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// Match returns the catalog language best matching an Accept-Language header,
+// trying each range by quality, then its primary subtag ("vi-VN" -> "vi")
+func (c *Catalog) Match(acceptLanguage string) string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var ranges []weighted
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && q > 0 {
+			ranges = append(ranges, weighted{tag, q})
+		}
+	}
+	slices.SortStableFunc(ranges, func(a, b weighted) int {
+		switch {
+		case a.q > b.q:
+			return -1
+		case a.q < b.q:
+			return 1
+		}
+		return 0
+	})
+
+	for _, r := range ranges {
+		if r.tag == "*" {
+			return c.fallback
+		}
+		if _, ok := c.messages[r.tag]; ok {
+			return r.tag
+		}
+		if primary, _, found := strings.Cut(r.tag, "-"); found {
+			if _, ok := c.messages[primary]; ok {
+				return primary
+			}
+		}
+	}
+	return c.fallback
+}
+
+// Message returns the text of key in lang, else in the fallback language, else key itself
+func (c *Catalog) Message(lang, key string) string {
+	if text, ok := c.messages[strings.ToLower(lang)][key]; ok {
+		return text
+	}
+	if text, ok := c.messages[c.fallback][key]; ok {
+		return text
+	}
+	return key
+}
+
+// Format returns the text of key in lang with each {name} placeholder replaced by params[name]
+func (c *Catalog) Format(lang, key string, params map[string]string) string {
+	text := c.Message(lang, key)
+	if len(params) == 0 {
+		return text
+	}
+	pairs := make([]string, 0, 2*len(params))
+	for name, value := range params {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+var defaultCatalog atomic.Pointer[Catalog]
+
+// SetDefault sets the catalog used by Message
+func SetDefault(c *Catalog) {
+	defaultCatalog.Store(c)
+}
+
+// Default returns the catalog used by Message, the embedded one unless SetDefault was called
+func Default() *Catalog {
+	if c := defaultCatalog.Load(); c != nil {
+		return c
+	}
+	c, err := New(DefaultLanguage)
+	if err != nil {
+		panic(err)
+	}
+	defaultCatalog.CompareAndSwap(nil, c)
+	return defaultCatalog.Load()
+}
+
+// Message returns the text of key in lang from the default catalog
+func Message(lang, key string) string {
+	return Default().Message(lang, key)
+}
+
+// Format returns the text of key in lang from the default catalog, with params filled in
+func Format(lang, key string, params map[string]string) string {
+	return Default().Format(lang, key, params)
+}
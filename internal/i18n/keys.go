@@ -0,0 +1,55 @@
+package i18n
+
+// Keys of the gateway-originated messages
+const (
+	Success                 = "success"
+	RequestValidationFailed = "request_validation_failed"
+	InvalidRequestBody      = "invalid_request_body"
+	InvalidMergePatch       = "invalid_merge_patch"
+	ReadRequestBodyFailed   = "read_request_body_failed"
+	InvalidUserID           = "invalid_user_id"
+	InvalidArticleID        = "invalid_article_id"
+	AuthorizationRequired   = "authorization_required"
+	UserServiceUnavailable  = "user_service_unavailable"
+	ETagMismatch            = "etag_mismatch"
+	RequestTimeout          = "request_timeout"
+	InvalidIdempotencyKey   = "invalid_idempotency_key"
+	IdempotencyInFlight     = "idempotency_in_flight"
+	IdempotencyKeyReused    = "idempotency_key_reused"
+)
+
+// Keys of field violation messages, {name} placeholders are filled from the FieldError params
+const (
+	FieldRequired     = "field_required"
+	FieldTypeInteger  = "field_type_integer"
+	FieldTypeNumber   = "field_type_number"
+	FieldTypeBoolean  = "field_type_boolean"
+	FieldTypeString   = "field_type_string"
+	FieldTypeArray    = "field_type_array"
+	FieldTypeObject   = "field_type_object"
+	FieldTypeNull     = "field_type_null"
+	FieldType         = "field_type"
+	FieldEnum         = "field_enum"
+	FieldMinimum      = "field_minimum"
+	FieldMaximum      = "field_maximum"
+	FieldInt32        = "field_int32"
+	FieldMinLength    = "field_min_length"
+	FieldMaxLength    = "field_max_length"
+	FieldPattern      = "field_pattern"
+	FieldEmail        = "field_email"
+	FieldDateTime     = "field_date_time"
+	FieldMinItems     = "field_min_items"
+	FieldMaxItems     = "field_max_items"
+	BodyRequired      = "body_required"
+	BodyInvalidJSON   = "body_invalid_json"
+	BodyTooLarge      = "body_too_large"
+	PasswordMissing   = "password_missing"
+	PasswordMinLength = "password_min_length"
+	PasswordUpper     = "password_upper"
+	PasswordLower     = "password_lower"
+	PasswordDigit     = "password_digit"
+	PasswordSymbol    = "password_symbol"
+	PasswordMaxBytes  = "password_max_bytes"
+	FieldNotPatchable = "field_not_patchable"
+	FieldStringOrNull = "field_string_or_null"
+)
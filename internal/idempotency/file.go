@@ -0,0 +1,148 @@
+package idempotency
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// maxLineSize bounds a persisted record, stored bodies are base64 encoded
+	maxLineSize = 4 << 20
+	// minCompactLines delays compaction of small files
+	minCompactLines = 512
+)
+
+// FileStore keeps records in memory and appends completed ones to a JSON-lines file,
+// so keys survive restarts. In-flight reservations are never persisted: a request
+// interrupted by a restart can be retried with the same key.
+type FileStore struct {
+	*MemoryStore
+
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	written int // Lines appended since the file was last compacted
+}
+
+// OpenFileStore loads the unexpired records of path and opens it for appending
+func OpenFileStore(path string) (*FileStore, error) {
+	s := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Complete stores the response of a reserved key and appends the record to the file
+func (s *FileStore) Complete(key string, resp Response) error {
+	rec, ok := s.complete(key, resp)
+	if !ok {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.written >= 2*max(s.Len(), minCompactLines) {
+		// The record is already in memory and written by the compaction
+		return s.compact()
+	}
+	if s.file == nil {
+		return fmt.Errorf("idempotency file %s is closed", s.path)
+	}
+	if err := json.NewEncoder(s.file).Encode(rec); err != nil {
+		return fmt.Errorf("append idempotency record: %w", err)
+	}
+	s.written++
+	return nil
+}
+
+// Close closes the file
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// load reads the persisted records, later lines replacing earlier ones
+func (s *FileStore) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open idempotency file: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), maxLineSize)
+	now := time.Now()
+	skipped := 0
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.Key == "" || rec.InFlight() {
+			skipped++
+			continue
+		}
+		if now.Before(rec.Expires) {
+			s.put(rec)
+		}
+	}
+	if skipped > 0 {
+		slog.Warn("Skipped unreadable idempotency records", "path", s.path, "lines", skipped)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("read idempotency file: %w", err)
+	}
+	return nil
+}
+
+// compact rewrites the file with the unexpired completed records and reopens it for appending
+func (s *FileStore) compact() error {
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+
+	records := s.completed()
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("compact idempotency file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, rec := range records {
+		enc.Encode(rec)
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("compact idempotency file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("compact idempotency file: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("compact idempotency file: %w", err)
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open idempotency file: %w", err)
+	}
+	s.written = len(records)
+	return nil
+}
@@ -0,0 +1,142 @@
+package idempotency
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often expired records are dropped from memory
+const sweepInterval = time.Minute
+
+// Response is the stored final response of a request
+type Response struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+}
+
+// Record is an idempotency key with the fingerprint of its first request
+type Record struct {
+	Key         string    `json:"key"`
+	Fingerprint string    `json:"fingerprint"`
+	Response    *Response `json:"response,omitempty"` // Nil while the first request is in flight
+	Expires     time.Time `json:"expires"`
+}
+
+// InFlight reports whether the first request with the key has not completed yet
+func (r *Record) InFlight() bool {
+	return r.Response == nil
+}
+
+// Store keeps idempotency keys and the responses of their requests
+type Store interface {
+	// Begin reserves key for a new request and returns nil, or returns the existing
+	// record of the key (in flight or completed) without changing it
+	Begin(key, fingerprint string, ttl time.Duration) (*Record, error)
+	// Complete stores the final response of a reserved key
+	Complete(key string, resp Response) error
+	// Release drops a reservation whose response is not kept, so the key can be retried
+	Release(key string) error
+	Close() error
+}
+
+// MemoryStore keeps records in memory, they are lost on restart
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]*Record
+	swept   time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]*Record), swept: time.Now()}
+}
+
+// Begin reserves key unless a record of it exists and has not expired
+func (s *MemoryStore) Begin(key, fingerprint string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.swept) >= sweepInterval {
+		s.sweep(now)
+	}
+	if rec, ok := s.records[key]; ok && now.Before(rec.Expires) {
+		existing := *rec
+		return &existing, nil
+	}
+	s.records[key] = &Record{Key: key, Fingerprint: fingerprint, Expires: now.Add(ttl)}
+	return nil, nil
+}
+
+// Complete stores the response of a reserved key; unknown keys are ignored
+func (s *MemoryStore) Complete(key string, resp Response) error {
+	s.complete(key, resp)
+	return nil
+}
+
+// complete stores the response and returns the completed record
+func (s *MemoryStore) complete(key string, resp Response) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec, ok := s.records[key]
+	if !ok {
+		return Record{}, false
+	}
+	rec.Response = &resp
+	return *rec, true
+}
+
+// Release drops the reservation of key if its request is still in flight
+func (s *MemoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec, ok := s.records[key]; ok && rec.InFlight() {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+// Len returns the number of records, including expired ones not swept yet
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// Close is a no-op
+func (s *MemoryStore) Close() error {
+	return nil
+}
+
+// put adds a completed record, e.g. loaded from a file
+func (s *MemoryStore) put(rec Record) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[rec.Key] = &rec
+}
+
+// completed returns the completed records that have not expired
+func (s *MemoryStore) completed() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	records := make([]Record, 0, len(s.records))
+	for _, rec := range s.records {
+		if !rec.InFlight() && now.Before(rec.Expires) {
+			records = append(records, *rec)
+		}
+	}
+	return records
+}
+
+// sweep drops expired records
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, rec := range s.records {
+		if !now.Before(rec.Expires) {
+			delete(s.records, key)
+		}
+	}
+	s.swept = now
+}
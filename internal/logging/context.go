@@ -0,0 +1,33 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+	"github.com/thatlq1812/service-3-gateway/internal/tracing"
+)
+
+// contextHandler adds the request ID and trace ID found in the context to every record,
+// so any line logged with a request context (InfoContext, Log, ...) can be correlated
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := reqctx.RequestIDFrom(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := tracing.SpanContextFromContext(ctx); sc.IsValid() && sc.Sampled() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID.String()), slog.String("span_id", sc.SpanID.String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
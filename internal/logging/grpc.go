@@ -0,0 +1,49 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// UnaryClientInterceptor records every call to backend in the request record
+// and logs it at debug level (warn when the call failed)
+func UnaryClientInterceptor(logger *slog.Logger, backend string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		duration := time.Since(start)
+
+		code := status.Code(err).String()
+		name := method[strings.LastIndex(method, "/")+1:]
+		reqctx.RecordFrom(ctx).AddCall(reqctx.Call{
+			Backend:  backend,
+			Method:   name,
+			Code:     code,
+			Duration: duration,
+		})
+
+		level := slog.LevelDebug
+		if err != nil {
+			level = slog.LevelWarn
+		}
+		logger.Log(ctx, level, "backend call",
+			"backend", backend,
+			"method", name,
+			"code", code,
+			"duration_ms", durationMillis(duration),
+		)
+		return err
+	}
+}
+
+// durationMillis converts a duration to fractional milliseconds
+func durationMillis(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
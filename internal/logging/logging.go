@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// Log formats supported by New
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// New creates a logger writing to w in the given format (json when empty).
+// The level can be changed at runtime through level; secrets are redacted from every record
+// and the request and trace IDs of the context, if any, are added to it.
+func New(w io.Writer, format string, level *slog.LevelVar) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttr,
+	}
+
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		return slog.New(contextHandler{slog.NewJSONHandler(w, opts)}), nil
+	case FormatText:
+		return slog.New(contextHandler{slog.NewTextHandler(w, opts)}), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (want json or text)", format)
+	}
+}
+
+// ParseLevel parses debug, info, warn or error (case insensitive)
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("unknown log level %q (want debug, info, warn or error)", s)
+	}
+	return level, nil
+}
+
+// levelBody is the JSON body of the log level admin endpoint
+type levelBody struct {
+	Level string `json:"level"`
+}
+
+// LevelHandler reports the current log level on GET and changes it on PUT,
+// e.g. PUT {"level": "debug"}
+func LevelHandler(level *slog.LevelVar) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var body levelBody
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": "invalid request body"})
+				return
+			}
+			parsed, err := ParseLevel(body.Level)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+				return
+			}
+			previous := level.Level()
+			level.Set(parsed)
+			slog.Info("log level changed", "from", previous.String(), "to", parsed.String())
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		json.NewEncoder(w).Encode(levelBody{Level: strings.ToLower(level.Level().String())})
+	})
+}
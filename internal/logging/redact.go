@@ -0,0 +1,134 @@
+package logging
+
+import (
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces the value of every secret in log records
+const Redacted = "[REDACTED]"
+
+// IsSensitiveKey reports whether a field, header or parameter name holds a secret:
+// password, token, authorization and their variants such as refresh_token or new_password
+func IsSensitiveKey(key string) bool {
+	key = strings.ToLower(strings.ReplaceAll(key, "-", "_"))
+	switch {
+	case key == "authorization", key == "proxy_authorization":
+		return true
+	case key == "password", strings.HasSuffix(key, "_password"):
+		return true
+	case key == "token", strings.HasSuffix(key, "_token"):
+		return true
+	default:
+		return false
+	}
+}
+
+// secretPattern matches key/value pairs with a sensitive key in free text:
+// query strings (token=abc), JSON ("password":"abc") and headers (Authorization: Bearer abc)
+var secretPattern = regexp.MustCompile(
+	`(?i)\b((?:[a-z_-]*[_-])?(?:password|token)|(?:proxy-)?authorization)\b` + // key
+		`(["']?\s*[:=]\s*["']?)` + // separator
+		`((?:bearer|basic)\s+)?` + // optional auth scheme
+		`[^\s"'&,;}]+`) // value
+
+// Redact masks the values of sensitive keys found in s
+func Redact(s string) string {
+	if !secretPattern.MatchString(s) {
+		return s
+	}
+	return secretPattern.ReplaceAllString(s, "${1}${2}${3}"+Redacted)
+}
+
+// RedactQuery masks sensitive parameters of a raw URL query
+func RedactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return ""
+	}
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return Redact(rawQuery)
+	}
+	redacted := false
+	for key := range values {
+		if IsSensitiveKey(key) {
+			values[key] = []string{Redacted}
+			redacted = true
+		}
+	}
+	if !redacted {
+		return rawQuery
+	}
+	return values.Encode()
+}
+
+// redactAttr is the slog ReplaceAttr hook masking secrets in every attribute, including the message
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if IsSensitiveKey(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Redact(a.Value.String()))
+	case slog.KindAny:
+		return slog.Any(a.Key, redactValue(a.Value.Any()))
+	default:
+		return a
+	}
+}
+
+// redactValue masks secrets in the common structured values passed to the logger
+func redactValue(v any) any {
+	switch v := v.(type) {
+	case error:
+		return Redact(v.Error())
+	case http.Header:
+		out := make(http.Header, len(v))
+		for key, values := range v {
+			if IsSensitiveKey(key) {
+				out[key] = []string{Redacted}
+			} else {
+				out[key] = values
+			}
+		}
+		return out
+	case url.Values:
+		out := make(url.Values, len(v))
+		for key, values := range v {
+			if IsSensitiveKey(key) {
+				out[key] = []string{Redacted}
+			} else {
+				out[key] = values
+			}
+		}
+		return out
+	case map[string]string:
+		out := make(map[string]string, len(v))
+		for key, value := range v {
+			if IsSensitiveKey(key) {
+				out[key] = Redacted
+			} else {
+				out[key] = Redact(value)
+			}
+		}
+		return out
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, value := range v {
+			if IsSensitiveKey(key) {
+				out[key] = Redacted
+			} else {
+				out[key] = redactValue(value)
+			}
+		}
+		return out
+	case string:
+		return Redact(v)
+	default:
+		return v
+	}
+}
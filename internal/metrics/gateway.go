@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/thatlq1812/service-3-gateway/internal/cache"
+	"github.com/thatlq1812/service-3-gateway/internal/circuit"
+)
+
+// Gateway is the set of metrics exported by the API gateway
+type Gateway struct {
+	Registry *Registry
+
+	httpRequests *CounterVec
+	httpDuration *HistogramVec
+	inFlight     *GaugeVec
+	timeouts     *CounterVec
+	grpcCalls    *CounterVec
+	grpcDuration *HistogramVec
+
+	breakers map[string]*circuit.Breaker
+	cache    *cache.Cache
+}
+
+// NewGateway registers the gateway metrics in a new registry
+func NewGateway() *Gateway {
+	reg := NewRegistry()
+	g := &Gateway{
+		Registry: reg,
+		httpRequests: reg.NewCounterVec("gateway_http_requests_total",
+			"HTTP requests handled, by mux route template, method and status code.",
+			"route", "method", "status"),
+		httpDuration: reg.NewHistogramVec("gateway_http_request_duration_seconds",
+			"HTTP request latency, by mux route template, method and status code.",
+			DefaultBuckets, "route", "method", "status"),
+		inFlight: reg.NewGaugeVec("gateway_http_requests_in_flight",
+			"HTTP requests currently being served."),
+		timeouts: reg.NewCounterVec("gateway_http_request_timeouts_total",
+			"HTTP requests aborted by the timeout middleware, by route template and method.",
+			"route", "method"),
+		grpcCalls: reg.NewCounterVec("gateway_grpc_client_calls_total",
+			"gRPC calls to backends, by service, method and gRPC status code.",
+			"service", "method", "code"),
+		grpcDuration: reg.NewHistogramVec("gateway_grpc_client_call_duration_seconds",
+			"gRPC call latency to backends, by service, method and gRPC status code.",
+			DefaultBuckets, "service", "method", "code"),
+		breakers: make(map[string]*circuit.Breaker),
+	}
+	// Export the gauge as 0 before the first request
+	g.inFlight.WithLabelValues()
+
+	reg.NewGaugeFunc("gateway_circuit_breaker_state",
+		"Circuit breaker state per backend: 0 closed, 1 open, 2 half-open.",
+		[]string{"service"}, func(emit func(float64, ...string)) {
+			for service, breaker := range g.breakers {
+				emit(float64(breaker.GetState()), service)
+			}
+		})
+	reg.NewGaugeFunc("gateway_circuit_breaker_failures",
+		"Consecutive failures counted by each circuit breaker.",
+		[]string{"service"}, func(emit func(float64, ...string)) {
+			for service, breaker := range g.breakers {
+				emit(float64(breaker.GetFailures()), service)
+			}
+		})
+
+	reg.NewGaugeFunc("gateway_response_cache_entries",
+		"Responses held by the response cache.",
+		nil, func(emit func(float64, ...string)) {
+			if g.cache != nil {
+				emit(float64(g.cache.Stats().Entries))
+			}
+		})
+	reg.NewGaugeFunc("gateway_response_cache_bytes",
+		"Approximate size of the responses held by the response cache.",
+		nil, func(emit func(float64, ...string)) {
+			if g.cache != nil {
+				emit(float64(g.cache.Stats().Bytes))
+			}
+		})
+	reg.NewCounterFunc("gateway_response_cache_lookups_total",
+		"Response cache lookups, by result: hit, miss or bypass.",
+		[]string{"result"}, func(emit func(float64, ...string)) {
+			if g.cache != nil {
+				stats := g.cache.Stats()
+				emit(float64(stats.Hits), "hit")
+				emit(float64(stats.Misses), "miss")
+				emit(float64(stats.Bypasses), "bypass")
+			}
+		})
+
+	return g
+}
+
+// RegisterCache exports the statistics of the response cache.
+// Must be called before the metrics endpoint is served.
+func (g *Gateway) RegisterCache(c *cache.Cache) {
+	g.cache = c
+}
+
+// RegisterBreaker exports the state of a circuit breaker.
+// Must be called before the metrics endpoint is served.
+func (g *Gateway) RegisterBreaker(service string, breaker *circuit.Breaker) {
+	g.breakers[service] = breaker
+}
+
+// RequestStarted increments the in-flight gauge
+func (g *Gateway) RequestStarted() {
+	g.inFlight.WithLabelValues().Inc()
+}
+
+// RequestFinished records a completed HTTP request and decrements the in-flight gauge
+func (g *Gateway) RequestFinished(route, method string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	g.inFlight.WithLabelValues().Dec()
+	g.httpRequests.WithLabelValues(route, method, code).Inc()
+	g.httpDuration.WithLabelValues(route, method, code).Observe(duration.Seconds())
+}
+
+// RequestTimedOut records a request aborted by the timeout middleware
+func (g *Gateway) RequestTimedOut(route, method string) {
+	g.timeouts.WithLabelValues(route, method).Inc()
+}
+
+// UnaryClientInterceptor records count and latency of every backend call
+func (g *Gateway) UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		service, name := SplitMethod(method)
+		code := status.Code(err).String()
+		g.grpcCalls.WithLabelValues(service, name, code).Inc()
+		g.grpcDuration.WithLabelValues(service, name, code).Observe(time.Since(start).Seconds())
+		return err
+	}
+}
+
+// SplitMethod splits a full gRPC method "/user.UserService/GetUser"
+// into service "user.UserService" and method "GetUser"
+func SplitMethod(fullMethod string) (service, method string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
@@ -0,0 +1,389 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are latency histogram buckets in seconds (same as the Prometheus client)
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// collector writes one metric family in Prometheus text format
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds metric families and serves them in Prometheus text format
+type Registry struct {
+	mu         sync.Mutex
+	collectors []collector
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// Handler serves all registered metrics (text exposition format 0.0.4)
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		r.mu.Lock()
+		collectors := append([]collector(nil), r.collectors...)
+		r.mu.Unlock()
+
+		bw := bufio.NewWriter(w)
+		for _, c := range collectors {
+			c.write(bw)
+		}
+		bw.Flush()
+	})
+}
+
+// family holds the label-keyed series of one metric
+type family struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+
+	mu     sync.RWMutex
+	series map[string]interface{}
+	labels map[string][]string
+}
+
+func newFamily(name, help, kind string, labelNames []string) *family {
+	return &family{
+		name:       name,
+		help:       help,
+		kind:       kind,
+		labelNames: labelNames,
+		series:     make(map[string]interface{}),
+		labels:     make(map[string][]string),
+	}
+}
+
+// get returns the series for the label values, creating it with create if needed
+func (f *family) get(values []string, create func() interface{}) interface{} {
+	if len(values) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labelNames), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.RLock()
+	s, ok := f.series[key]
+	f.mu.RUnlock()
+	if ok {
+		return s
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if s, ok = f.series[key]; !ok {
+		s = create()
+		f.series[key] = s
+		f.labels[key] = append([]string(nil), values...)
+	}
+	return s
+}
+
+// sortedKeys returns series keys in a stable order
+func (f *family) sortedKeys() []string {
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *family) writeHeader(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.kind)
+}
+
+// atomicFloat is a float64 guarded by a mutex
+type atomicFloat struct {
+	mu    sync.Mutex
+	value float64
+}
+
+func (a *atomicFloat) add(v float64) {
+	a.mu.Lock()
+	a.value += v
+	a.mu.Unlock()
+}
+
+func (a *atomicFloat) set(v float64) {
+	a.mu.Lock()
+	a.value = v
+	a.mu.Unlock()
+}
+
+func (a *atomicFloat) get() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.value
+}
+
+// CounterVec is a monotonically increasing metric partitioned by labels
+type CounterVec struct {
+	f *family
+}
+
+// Counter is one series of a CounterVec
+type Counter struct {
+	v *atomicFloat
+}
+
+// NewCounterVec registers a counter family
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	c := &CounterVec{f: newFamily(name, help, "counter", labelNames)}
+	r.register(c)
+	return c
+}
+
+// WithLabelValues returns the counter for the given label values
+func (c *CounterVec) WithLabelValues(values ...string) Counter {
+	return Counter{v: c.f.get(values, func() interface{} { return &atomicFloat{} }).(*atomicFloat)}
+}
+
+// Inc adds one
+func (c Counter) Inc() {
+	c.v.add(1)
+}
+
+// Add adds a non-negative value
+func (c Counter) Add(v float64) {
+	if v > 0 {
+		c.v.add(v)
+	}
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	writeSimple(w, c.f)
+}
+
+// GaugeVec is a metric that can go up and down, partitioned by labels
+type GaugeVec struct {
+	f *family
+}
+
+// Gauge is one series of a GaugeVec
+type Gauge struct {
+	v *atomicFloat
+}
+
+// NewGaugeVec registers a gauge family
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	g := &GaugeVec{f: newFamily(name, help, "gauge", labelNames)}
+	r.register(g)
+	return g
+}
+
+// WithLabelValues returns the gauge for the given label values
+func (g *GaugeVec) WithLabelValues(values ...string) Gauge {
+	return Gauge{v: g.f.get(values, func() interface{} { return &atomicFloat{} }).(*atomicFloat)}
+}
+
+// Inc adds one
+func (g Gauge) Inc() {
+	g.v.add(1)
+}
+
+// Dec subtracts one
+func (g Gauge) Dec() {
+	g.v.add(-1)
+}
+
+// Set replaces the value
+func (g Gauge) Set(v float64) {
+	g.v.set(v)
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	writeSimple(w, g.f)
+}
+
+// writeSimple writes a counter or gauge family
+func writeSimple(w *bufio.Writer, f *family) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	f.writeHeader(w)
+	for _, key := range f.sortedKeys() {
+		value := f.series[key].(*atomicFloat).get()
+		fmt.Fprintf(w, "%s%s %s\n", f.name, formatLabels(f.labelNames, f.labels[key], "", ""), formatValue(value))
+	}
+}
+
+// GaugeFunc is a gauge (or counter) family whose series are computed at scrape time
+type GaugeFunc struct {
+	name       string
+	help       string
+	kind       string
+	labelNames []string
+	collect    func(emit func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc registers a gauge family computed by collect on every scrape
+func (r *Registry) NewGaugeFunc(name, help string, labelNames []string, collect func(emit func(value float64, labelValues ...string))) {
+	r.register(&GaugeFunc{name: name, help: help, kind: "gauge", labelNames: labelNames, collect: collect})
+}
+
+// NewCounterFunc registers a counter family computed by collect on every scrape,
+// for totals kept elsewhere; collect must only emit values that never decrease
+func (r *Registry) NewCounterFunc(name, help string, labelNames []string, collect func(emit func(value float64, labelValues ...string))) {
+	r.register(&GaugeFunc{name: name, help: help, kind: "counter", labelNames: labelNames, collect: collect})
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", g.name, escapeHelp(g.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", g.name, g.kind)
+	g.collect(func(value float64, labelValues ...string) {
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labelNames, labelValues, "", ""), formatValue(value))
+	})
+}
+
+// HistogramVec samples observations into buckets, partitioned by labels
+type HistogramVec struct {
+	f       *family
+	buckets []float64
+}
+
+// Histogram is one series of a HistogramVec
+type Histogram struct {
+	h       *histogram
+	buckets []float64
+}
+
+type histogram struct {
+	mu     sync.Mutex
+	counts []uint64 // per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registers a histogram family with the given upper bounds
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+	h := &HistogramVec{f: newFamily(name, help, "histogram", labelNames), buckets: buckets}
+	r.register(h)
+	return h
+}
+
+// WithLabelValues returns the histogram for the given label values
+func (h *HistogramVec) WithLabelValues(values ...string) Histogram {
+	s := h.f.get(values, func() interface{} {
+		return &histogram{counts: make([]uint64, len(h.buckets))}
+	}).(*histogram)
+	return Histogram{h: s, buckets: h.buckets}
+}
+
+// Observe records one value
+func (h Histogram) Observe(v float64) {
+	// First bucket whose upper bound is >= v
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.h.mu.Lock()
+	defer h.h.mu.Unlock()
+	if i < len(h.h.counts) {
+		h.h.counts[i]++
+	}
+	h.h.count++
+	h.h.sum += v
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.f.mu.RLock()
+	defer h.f.mu.RUnlock()
+
+	h.f.writeHeader(w)
+	for _, key := range h.f.sortedKeys() {
+		s := h.f.series[key].(*histogram)
+		values := h.f.labels[key]
+
+		s.mu.Lock()
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.f.name, formatLabels(h.f.labelNames, values, "le", formatValue(bound)), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.f.name, formatLabels(h.f.labelNames, values, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.f.name, formatLabels(h.f.labelNames, values, "", ""), formatValue(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.f.name, formatLabels(h.f.labelNames, values, "", ""), s.count)
+		s.mu.Unlock()
+	}
+}
+
+// formatLabels renders {name="value",...}, with an optional extra label (e.g. le)
+func formatLabels(names, values []string, extraName, extraValue string) string {
+	if len(names) == 0 && extraName == "" {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, name := range names {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		sb.WriteString(name)
+		sb.WriteString(`="`)
+		sb.WriteString(escapeLabel(value))
+		sb.WriteByte('"')
+	}
+	if extraName != "" {
+		if len(names) > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(extraName)
+		sb.WriteString(`="`)
+		sb.WriteString(extraValue)
+		sb.WriteByte('"')
+	}
+	sb.WriteByte('}')
+	return sb.String()
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	}
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/accesslog"
+	"github.com/thatlq1812/service-3-gateway/internal/logging"
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// AccessLogMiddleware writes one access log line per request, after the response
+// status is known. It must run inside LoggingMiddleware to see the request principal.
+func AccessLogMiddleware(logger *accesslog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			recorder := newResponseRecorder(w)
+			next.ServeHTTP(recorder, r)
+
+			uri := r.URL.EscapedPath()
+			if query := logging.RedactQuery(r.URL.RawQuery); query != "" {
+				uri += "?" + query
+			}
+			entry := accesslog.Entry{
+				Time:      start,
+				RemoteIP:  clientIP(r),
+				Method:    r.Method,
+				URI:       uri,
+				Proto:     r.Proto,
+				Route:     RouteTemplate(r),
+				Status:    recorder.Status(),
+				Bytes:     recorder.bytes,
+				Duration:  time.Since(start),
+				Referer:   r.Referer(),
+				UserAgent: r.UserAgent(),
+				RequestID: reqctx.RequestIDFrom(r.Context()),
+			}
+			if principal, ok := reqctx.RecordFrom(r.Context()).Principal(); ok {
+				entry.Principal = principal.Name
+			}
+
+			if err := logger.Log(entry); err != nil {
+				slog.ErrorContext(r.Context(), "Failed to write access log", "error", err)
+			}
+		})
+	}
+}
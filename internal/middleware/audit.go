@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/thatlq1812/service-3-gateway/internal/audit"
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// auditBodyLimit bounds how much of a request or response body is inspected for the audit entry
+const auditBodyLimit = 1 << 20
+
+// AuditMiddleware writes an audit entry for every request matching one of actions,
+// keyed by method and route template, e.g. "PUT /api/v1/users/{id}".
+// Only the names of the JSON body fields are recorded, never their values.
+func AuditMiddleware(logger *audit.Logger, actions map[string]audit.Action) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			action, ok := actions[r.Method+" "+RouteTemplate(r)]
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Read the body for its field names and hand the handler an identical copy
+			body, err := io.ReadAll(io.LimitReader(r.Body, auditBodyLimit))
+			if err != nil {
+				body = nil
+			}
+			r.Body = struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+
+			recorder := &auditRecorder{responseRecorder: newResponseRecorder(w)}
+			next.ServeHTTP(recorder, r)
+
+			code, createdID := auditOutcome(recorder.body.Bytes())
+			if timeoutAnswered(w) {
+				// The client got the timeout answer, the late response only tells the created id
+				code = timeoutCode
+			}
+			entry := audit.Entry{
+				Action:    action.Name,
+				Resource:  action.Resource,
+				TargetID:  mux.Vars(r)["id"],
+				ClientIP:  clientIP(r),
+				Fields:    fieldNames(body),
+				Status:    recorder.Status(),
+				Code:      code,
+				RequestID: reqctx.RequestIDFrom(r.Context()),
+			}
+			if entry.TargetID == "" {
+				entry.TargetID = createdID
+			}
+			entry.Principal, entry.PrincipalSource = auditPrincipal(r)
+
+			if err := logger.Log(entry); err != nil {
+				slog.ErrorContext(r.Context(), "Failed to write audit entry",
+					"action", action.Name, "target_id", entry.TargetID, "error", err)
+			}
+		})
+	}
+}
+
+// auditRecorder keeps the first auditBodyLimit bytes of the response body
+type auditRecorder struct {
+	*responseRecorder
+	body bytes.Buffer
+}
+
+func (rec *auditRecorder) Write(b []byte) (int, error) {
+	if room := auditBodyLimit - rec.body.Len(); room > 0 {
+		rec.body.Write(b[:min(len(b), room)])
+	}
+	return rec.responseRecorder.Write(b)
+}
+
+// auditOutcome extracts the API code and the id of a created entity from an APIResponse body
+func auditOutcome(body []byte) (code, id string) {
+	var resp struct {
+		Code string          `json:"code"`
+		Data json.RawMessage `json:"data"` // Object on success, field errors on validation failures
+	}
+	if json.Unmarshal(body, &resp) != nil {
+		return "", ""
+	}
+	var data struct {
+		ID json.RawMessage `json:"id"`
+	}
+	json.Unmarshal(resp.Data, &data)
+	return resp.Code, strings.Trim(string(data.ID), `"`)
+}
+
+// fieldNames returns the sorted top-level keys of a JSON object body
+func fieldNames(body []byte) []string {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(body, &fields) != nil {
+		return nil
+	}
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// clientIP returns the host part of the remote address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// auditPrincipal returns the acting principal: the mTLS principal if any, else the
+// subject of the bearer token. The token signature is checked by the backends, not here,
+// so a "jwt" principal is only trustworthy for requests the backend accepted.
+func auditPrincipal(r *http.Request) (name, source string) {
+	if p, ok := reqctx.PrincipalFrom(r.Context()); ok {
+		return p.Name, p.Source
+	}
+
+	authHeader := r.Header.Get("Authorization")
+	scheme, token, ok := strings.Cut(authHeader, " ")
+	if ok && strings.EqualFold(scheme, "bearer") {
+		if subject := tokenSubject(token); subject != "" {
+			return subject, "jwt"
+		}
+	}
+	return "", "anonymous"
+}
+
+// tokenSubject decodes the claims of a JWT (without verifying it) and returns its subject
+func tokenSubject(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	var claims map[string]any
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+	for _, key := range []string{"sub", "user_id", "email"} {
+		switch v := claims[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/thatlq1812/service-3-gateway/internal/cache"
+)
+
+const (
+	// CacheStatusHeader tells whether a response came from the cache: HIT, MISS or BYPASS
+	CacheStatusHeader = "X-Cache"
+
+	// cacheBodyLimit bounds the size of a cached response body
+	cacheBodyLimit = 1 << 20
+)
+
+// cachedHeaders are the response headers stored with a cached response
+var cachedHeaders = []string{"Content-Type", "Content-Language", "ETag"}
+
+// CacheRoute is the caching rule of a GET route
+type CacheRoute struct {
+	TTL  time.Duration // 0 disables caching of the route
+	Tags []string      // Invalidation tags, route variables expanded, e.g. "user:{id}"
+}
+
+// CacheConfig controls ResponseCacheMiddleware
+type CacheConfig struct {
+	Cache *cache.Cache
+	// Routes maps "GET <route template>" to its caching rule
+	Routes map[string]CacheRoute
+	// Invalidations maps write routes, e.g. "PUT /api/v1/users/{id}", to the tags they invalidate
+	Invalidations map[string][]string
+}
+
+// ResponseCacheMiddleware serves successful responses of the configured GET routes from
+// an in-process LRU cache for the route's TTL, keyed by path, query and response language.
+// Responses carry Cache-Control: private, max-age=<TTL> (they include user emails, so shared
+// caches must not store them), Age on hits and X-Cache.
+// Clients sending Cache-Control: no-cache get a fresh response, which is cached again;
+// no-store skips the cache entirely. Writes handled by the gateway invalidate the tags
+// of their route once the backend answered, unless they failed with a 4xx status.
+func ResponseCacheMiddleware(cfg CacheConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Cache == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := r.Method + " " + RouteTemplate(r)
+			if tags, ok := cfg.Invalidations[route]; ok {
+				recorder := newResponseRecorder(w)
+				next.ServeHTTP(recorder, r)
+				// A timed out write may still complete in the backend
+				if status := recorder.Status(); status < 400 || status >= 500 {
+					cfg.Cache.Invalidate(expandTags(tags, mux.Vars(r))...)
+				}
+				return
+			}
+
+			rule, ok := cfg.Routes[route]
+			if !ok || rule.TTL <= 0 || r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			noCache, noStore := requestCacheDirectives(r.Header)
+			if noStore {
+				cfg.Cache.Bypass()
+				w.Header().Set(CacheStatusHeader, "BYPASS")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			key := r.URL.Path + "?" + r.URL.Query().Encode() + "\x00" + w.Header().Get("Content-Language")
+			if noCache {
+				cfg.Cache.Bypass()
+			} else if entry, ok := cfg.Cache.Get(key); ok {
+				writeCached(w, r, entry, rule.TTL)
+				return
+			}
+
+			generation := cfg.Cache.Generation()
+			cw := &cacheWriter{responseRecorder: newResponseRecorder(w), ttl: rule.TTL}
+			next.ServeHTTP(cw, r)
+			if cw.Status() != http.StatusOK || cw.truncated {
+				return
+			}
+
+			now := time.Now()
+			entry := &cache.Entry{
+				Status:  cw.status,
+				Header:  make(http.Header),
+				Body:    cw.body.Bytes(),
+				Stored:  now,
+				Expires: now.Add(rule.TTL),
+				Tags:    expandTags(rule.Tags, mux.Vars(r)),
+			}
+			for _, name := range cachedHeaders {
+				if values := w.Header().Values(name); len(values) > 0 {
+					entry.Header[http.CanonicalHeaderKey(name)] = values
+				}
+			}
+			cfg.Cache.Set(key, entry, generation)
+		})
+	}
+}
+
+// cacheWriter sets the cache headers of a cacheable response and keeps its body
+type cacheWriter struct {
+	*responseRecorder
+	ttl       time.Duration
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (cw *cacheWriter) WriteHeader(code int) {
+	if !cw.wroteHeader && (code == http.StatusOK || code == http.StatusNotModified) {
+		setCacheHeaders(cw.Header(), cw.ttl, "MISS")
+	}
+	cw.responseRecorder.WriteHeader(code)
+}
+
+func (cw *cacheWriter) Write(b []byte) (int, error) {
+	if !cw.wroteHeader {
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.body.Len()+len(b) > cacheBodyLimit {
+		cw.truncated = true
+	} else if !cw.truncated {
+		cw.body.Write(b)
+	}
+	return cw.responseRecorder.Write(b)
+}
+
+// writeCached writes a cache hit, or 304 when If-None-Match matches its ETag
+func writeCached(w http.ResponseWriter, r *http.Request, entry *cache.Entry, ttl time.Duration) {
+	for name, values := range entry.Header {
+		w.Header()[name] = values
+	}
+	setCacheHeaders(w.Header(), ttl, "HIT")
+	w.Header().Set("Age", strconv.FormatInt(int64(entry.Age(time.Now())/time.Second), 10))
+
+	if etag := entry.Header.Get("ETag"); etag != "" && ifNoneMatch(r.Header.Get("If-None-Match"), etag) {
+		w.Header().Del("Content-Type")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(entry.Status)
+	w.Write(entry.Body)
+}
+
+// setCacheHeaders replaces the default no-store of API routes with the route's TTL,
+// for the client only: the responses include user emails
+func setCacheHeaders(h http.Header, ttl time.Duration, status string) {
+	h.Set("Cache-Control", "private, max-age="+strconv.FormatInt(int64(ttl/time.Second), 10))
+	h.Del("Pragma")
+	h.Set(CacheStatusHeader, status)
+}
+
+// requestCacheDirectives reads no-cache (also max-age=0 and Pragma: no-cache) and no-store
+func requestCacheDirectives(h http.Header) (noCache, noStore bool) {
+	cacheControl := h.Values("Cache-Control")
+	if len(cacheControl) == 0 {
+		return strings.Contains(strings.ToLower(h.Get("Pragma")), "no-cache"), false
+	}
+	for _, value := range cacheControl {
+		for _, directive := range strings.Split(value, ",") {
+			switch strings.ToLower(strings.TrimSpace(directive)) {
+			case "no-cache", "max-age=0":
+				noCache = true
+			case "no-store":
+				noStore = true
+			}
+		}
+	}
+	return noCache, noStore
+}
+
+// ifNoneMatch compares an If-None-Match header to an ETag with the weak comparison
+func ifNoneMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// expandTags replaces {name} in tags with the route variables,
+// integers in canonical form so that /users/007 and /users/7 share their tags
+func expandTags(tags []string, vars map[string]string) []string {
+	pairs := make([]string, 0, 2*len(vars))
+	for name, value := range vars {
+		if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+			value = strconv.FormatInt(n, 10)
+		}
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	replacer := strings.NewReplacer(pairs...)
+	expanded := make([]string, len(tags))
+	for i, tag := range tags {
+		expanded[i] = replacer.Replace(tag)
+	}
+	return expanded
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// ClientCertMiddleware maps a verified TLS client certificate to a request principal.
+// principals maps a certificate subject (full DN or common name) to a principal name;
+// unmapped certificates use their common name.
+func ClientCertMiddleware(principals map[string]string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// VerifiedChains is only populated when the chain was checked against client CAs
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			subject := r.TLS.VerifiedChains[0][0].Subject
+			name, ok := principals[subject.String()]
+			if !ok {
+				name, ok = principals[subject.CommonName]
+			}
+			if !ok {
+				name = subject.CommonName
+			}
+
+			ctx := reqctx.WithPrincipal(r.Context(), reqctx.Principal{
+				Name:    name,
+				Source:  "mtls",
+				Subject: subject.String(),
+			})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
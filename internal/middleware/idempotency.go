@@ -0,0 +1,178 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/idempotency"
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header carrying the client's idempotency key
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from the store
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	// idempotencyBodyLimit bounds the request body fingerprinted and the response body stored
+	idempotencyBodyLimit = 1 << 20
+)
+
+// idempotencyHeaders are the response headers stored with the response and replayed
+var idempotencyHeaders = []string{"Content-Type", "Content-Language", "ETag", "Location"}
+
+// IdempotencyConfig controls which requests honour the Idempotency-Key header
+type IdempotencyConfig struct {
+	Store  idempotency.Store
+	TTL    time.Duration   // How long a key and its response are kept
+	Routes map[string]bool // Method and route template, e.g. "POST /api/v1/users"
+}
+
+// IdempotencyMiddleware makes the configured routes safe to retry with an Idempotency-Key
+// header. The first request with a key runs and its final response is stored with a
+// fingerprint of the body; retries with the same body get the stored response, with
+// Idempotent-Replayed: true, without reaching the backend. A retry while the first request
+// is still running gets 409 and a key reused with a different body gets 422.
+// Keys are scoped to the route and the caller's credentials. Responses with status >= 500
+// are not stored, so the request can be retried with the same key.
+func IdempotencyMiddleware(cfg IdempotencyConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Store == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, ok := r.Header[IdempotencyKeyHeader]
+			if !ok || !cfg.Routes[r.Method+" "+RouteTemplate(r)] {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) != 1 || !validIdempotencyKey(key[0]) {
+				response.BadRequest(w, i18n.InvalidIdempotencyKey)
+				return
+			}
+
+			body, err := io.ReadAll(io.LimitReader(r.Body, idempotencyBodyLimit+1))
+			if err != nil {
+				response.BadRequest(w, i18n.ReadRequestBodyFailed)
+				return
+			}
+			if len(body) > idempotencyBodyLimit {
+				// Too large to fingerprint, let the request through as if it had no key
+				r.Body = struct {
+					io.Reader
+					io.Closer
+				}{io.MultiReader(bytes.NewReader(body), r.Body), r.Body}
+				next.ServeHTTP(w, r)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scoped := idempotencyScope(r, key[0])
+			sum := sha256.Sum256(body)
+			fingerprint := hex.EncodeToString(sum[:])
+
+			existing, err := cfg.Store.Begin(scoped, fingerprint, cfg.TTL)
+			if err != nil {
+				slog.WarnContext(r.Context(), "Idempotency store unavailable, request not deduplicated", "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if existing != nil {
+				switch {
+				case existing.Fingerprint != fingerprint:
+					response.UnprocessableEntity(w, i18n.IdempotencyKeyReused)
+				case existing.InFlight():
+					w.Header().Set("Retry-After", "1")
+					response.Conflict(w, i18n.IdempotencyInFlight)
+				default:
+					replayResponse(w, existing.Response)
+				}
+				return
+			}
+
+			recorder := &idempotencyRecorder{responseRecorder: newResponseRecorder(w)}
+			completed := false
+			defer func() {
+				// Also releases the key when the handler panics
+				if !completed {
+					cfg.Store.Release(scoped)
+				}
+			}()
+			next.ServeHTTP(recorder, r)
+
+			// A timed out request is released: the client got a 504, not the late response
+			if recorder.Status() >= http.StatusInternalServerError || recorder.truncated {
+				return
+			}
+			stored := idempotency.Response{Status: recorder.status, Header: make(http.Header), Body: recorder.body.Bytes()}
+			for _, name := range idempotencyHeaders {
+				if values := w.Header().Values(name); len(values) > 0 {
+					stored.Header[http.CanonicalHeaderKey(name)] = values
+				}
+			}
+			if err := cfg.Store.Complete(scoped, stored); err != nil {
+				slog.ErrorContext(r.Context(), "Failed to store idempotent response", "error", err)
+			}
+			completed = true
+		})
+	}
+}
+
+// idempotencyRecorder keeps the response body, unless it exceeds idempotencyBodyLimit
+type idempotencyRecorder struct {
+	*responseRecorder
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (rec *idempotencyRecorder) Write(b []byte) (int, error) {
+	if rec.body.Len()+len(b) > idempotencyBodyLimit {
+		rec.truncated = true
+	} else if !rec.truncated {
+		rec.body.Write(b)
+	}
+	return rec.responseRecorder.Write(b)
+}
+
+// replayResponse writes a stored response
+func replayResponse(w http.ResponseWriter, resp *idempotency.Response) {
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// idempotencyScope hashes the key with the route and the caller's credentials,
+// so two callers using the same key never see each other's responses
+func idempotencyScope(r *http.Request, key string) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+RouteTemplate(r)+"\x00")
+	if p, ok := reqctx.PrincipalFrom(r.Context()); ok {
+		io.WriteString(h, p.Source+":"+p.Name)
+	}
+	io.WriteString(h, "\x00"+r.Header.Get("Authorization")+"\x00"+key)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// validIdempotencyKey accepts 1 to 255 printable ASCII characters
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+)
+
+// LocaleMiddleware selects the language of gateway messages from Accept-Language
+// and announces it with Content-Language, which the response package reads back
+func LocaleMiddleware(catalog *i18n.Catalog) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Language")
+			w.Header().Set("Content-Language", catalog.Match(r.Header.Get("Accept-Language")))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/logging"
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// LoggingMiddleware writes one structured access line per request with route, status,
+// bytes, duration, backends and principal. The query string is logged with secrets masked.
+func LoggingMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ctx, rec := reqctx.WithRecord(r.Context())
+
+			recorder := newResponseRecorder(w)
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+			duration := time.Since(start)
+			status := recorder.Status()
+
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("route", RouteTemplate(r)),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", status),
+				slog.Int64("bytes", recorder.bytes),
+				slog.Float64("duration_ms", float64(duration.Microseconds())/1000),
+				slog.String("remote_addr", r.RemoteAddr),
+			}
+			if query := logging.RedactQuery(r.URL.RawQuery); query != "" {
+				attrs = append(attrs, slog.String("query", query))
+			}
+			if backends := rec.Backends(); len(backends) > 0 {
+				attrs = append(attrs, slog.String("backend", strings.Join(backends, ",")))
+			}
+			if principal, ok := rec.Principal(); ok {
+				attrs = append(attrs, slog.String("principal", principal.Name))
+			}
+
+			level := slog.LevelInfo
+			if status >= http.StatusInternalServerError {
+				level = slog.LevelError
+			}
+			logger.LogAttrs(ctx, level, "request completed", attrs...)
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/metrics"
+)
+
+// MetricsMiddleware records request count, latency and in-flight requests per route template
+func MetricsMiddleware(m *metrics.Gateway) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			m.RequestStarted()
+
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r)
+
+			m.RequestFinished(RouteTemplate(r), r.Method, rec.status, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+)
+
+// Error formats
+const (
+	ErrorFormatEnvelope  = "envelope"  // Always the {code, message, data} envelope
+	ErrorFormatNegotiate = "negotiate" // problem+json when the client accepts it
+	ErrorFormatProblem   = "problem"   // Always application/problem+json
+)
+
+// ProblemConfig selects how error responses are rendered
+type ProblemConfig struct {
+	Format   string // ErrorFormatEnvelope, ErrorFormatNegotiate or ErrorFormatProblem
+	TypeBase string // Prefix of the problem type URI, empty for "about:blank"
+}
+
+// ProblemMiddleware renders error envelopes (status >= 400) as RFC 7807 application/problem+json.
+// It must wrap every middleware writing errors, right after RequestIDMiddleware.
+func ProblemMiddleware(cfg ProblemConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Format == ErrorFormatEnvelope {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if cfg.Format == ErrorFormatNegotiate {
+				w.Header().Add("Vary", "Accept")
+				if !acceptsProblem(r.Header.Get("Accept")) {
+					next.ServeHTTP(w, r)
+					return
+				}
+			}
+
+			pw := &problemWriter{ResponseWriter: w}
+			next.ServeHTTP(pw, r)
+			pw.finish(cfg.TypeBase, r.URL.Path)
+		})
+	}
+}
+
+// acceptsProblem reports whether the Accept header lists application/problem+json with q > 0
+func acceptsProblem(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || mediaType != response.ProblemContentType {
+			continue
+		}
+		if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+			return false
+		}
+		return true
+	}
+	return false
+}
+
+// problemWriter holds back JSON error responses so they can be rewritten
+type problemWriter struct {
+	http.ResponseWriter
+
+	mu          sync.Mutex
+	wroteHeader bool
+	status      int
+	buf         *bytes.Buffer // Captured error body, nil when passing through
+}
+
+func (pw *problemWriter) WriteHeader(code int) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	pw.writeHeader(code)
+}
+
+func (pw *problemWriter) writeHeader(code int) {
+	if pw.wroteHeader {
+		return
+	}
+	pw.wroteHeader = true
+	mediaType, _, _ := mime.ParseMediaType(pw.Header().Get("Content-Type"))
+	if code >= 400 && mediaType == "application/json" {
+		pw.status, pw.buf = code, new(bytes.Buffer)
+		return
+	}
+	pw.ResponseWriter.WriteHeader(code)
+}
+
+func (pw *problemWriter) Write(b []byte) (int, error) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	pw.writeHeader(http.StatusOK)
+	if pw.buf != nil {
+		return pw.buf.Write(b)
+	}
+	return pw.ResponseWriter.Write(b)
+}
+
+func (pw *problemWriter) Flush() {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if pw.buf != nil {
+		return
+	}
+	if f, ok := pw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (pw *problemWriter) Unwrap() http.ResponseWriter {
+	return pw.ResponseWriter
+}
+
+// finish writes the captured error as a problem, or unchanged if it is not an envelope
+func (pw *problemWriter) finish(typeBase, instance string) {
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+	if pw.buf == nil {
+		return
+	}
+	body := pw.buf.Bytes()
+	pw.buf = nil
+
+	var envelope struct {
+		Code      string          `json:"code"`
+		Message   string          `json:"message"`
+		Data      json.RawMessage `json:"data"`
+		RequestID string          `json:"request_id"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Code == "" {
+		pw.ResponseWriter.WriteHeader(pw.status)
+		pw.ResponseWriter.Write(body)
+		return
+	}
+
+	response.WriteProblem(pw.ResponseWriter, response.NewProblem(pw.status, response.APIResponse{
+		Code:      envelope.Code,
+		Message:   envelope.Message,
+		Data:      envelope.Data,
+		RequestID: envelope.RequestID,
+	}, typeBase, instance))
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// maxRequestIDLength bounds client supplied request IDs
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware keeps a valid incoming X-Request-ID or generates one,
+// stores it in the request context and echoes it in the response header
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(reqctx.RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+			r.Header.Set(reqctx.RequestIDHeader, id)
+		}
+
+		// Set before the handler runs so error responses can include it in the body
+		w.Header().Set(reqctx.RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(reqctx.WithRequestID(r.Context(), id)))
+	})
+}
+
+// validRequestID accepts IDs made of letters, digits and . _ : - only,
+// so client input cannot inject anything into logs or gRPC metadata
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '.', c == '_', c == ':', c == '-':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns a random UUID v4
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// Server-Timing modes
+const (
+	ServerTimingOff   = "off"   // Never send the header
+	ServerTimingAll   = "all"   // Send the header on every response
+	ServerTimingAdmin = "admin" // Send the header to admin principals only
+)
+
+// ServerTimingConfig controls the Server-Timing response header
+type ServerTimingConfig struct {
+	Mode   string          // ServerTimingOff, ServerTimingAll or ServerTimingAdmin
+	Admins map[string]bool // Principals allowed to see timings in admin mode
+}
+
+// allowed reports whether the caller of a request may see the timings
+func (cfg ServerTimingConfig) allowed(rec *reqctx.Record) bool {
+	switch cfg.Mode {
+	case ServerTimingAll:
+		return true
+	case ServerTimingAdmin:
+		principal, ok := rec.Principal()
+		return ok && cfg.Admins[principal.Name]
+	default:
+		return false
+	}
+}
+
+// ServerTimingMiddleware adds a Server-Timing header with the time spent in each
+// backend and in the gateway itself, e.g. "article;dur=42.1, user;dur=7.3, gw;dur=3.2".
+// Backend calls are read from the request record, filled in by the backend interceptors.
+func ServerTimingMiddleware(cfg ServerTimingConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if cfg.Mode != ServerTimingAll && cfg.Mode != ServerTimingAdmin {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			rec := reqctx.RecordFrom(ctx)
+			if rec == nil {
+				ctx, rec = reqctx.WithRecord(ctx)
+			}
+
+			tw := &timingWriter{ResponseWriter: w, start: time.Now(), rec: rec, cfg: cfg}
+			next.ServeHTTP(tw, r.WithContext(ctx))
+		})
+	}
+}
+
+// timingWriter sets the Server-Timing header just before the response header is written,
+// so it covers every backend call made before the response
+type timingWriter struct {
+	http.ResponseWriter
+	start       time.Time
+	rec         *reqctx.Record
+	cfg         ServerTimingConfig
+	wroteHeader bool
+}
+
+func (tw *timingWriter) WriteHeader(code int) {
+	if !tw.wroteHeader {
+		tw.wroteHeader = true
+		if tw.cfg.allowed(tw.rec) {
+			tw.Header().Set("Server-Timing", formatServerTiming(tw.rec.Calls(), time.Since(tw.start)))
+		}
+	}
+	tw.ResponseWriter.WriteHeader(code)
+}
+
+func (tw *timingWriter) Write(b []byte) (int, error) {
+	if !tw.wroteHeader {
+		tw.WriteHeader(http.StatusOK)
+	}
+	return tw.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming handlers
+func (tw *timingWriter) Flush() {
+	if f, ok := tw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (tw *timingWriter) Unwrap() http.ResponseWriter {
+	return tw.ResponseWriter
+}
+
+// formatServerTiming sums the call durations per backend (in first call order) and
+// reports the rest of total as gateway time. Backend calls made concurrently can
+// add up to more than total, gw is then 0.
+func formatServerTiming(calls []reqctx.Call, total time.Duration) string {
+	var names []string
+	durations := make(map[string]time.Duration)
+	counts := make(map[string]int)
+	var backendTotal time.Duration
+	for _, call := range calls {
+		name := serverTimingName(call.Backend)
+		if _, ok := durations[name]; !ok {
+			names = append(names, name)
+		}
+		durations[name] += call.Duration
+		counts[name]++
+		backendTotal += call.Duration
+	}
+
+	metrics := make([]string, 0, len(names)+1)
+	for _, name := range names {
+		metric := fmt.Sprintf("%s;dur=%s", name, formatMillis(durations[name]))
+		if counts[name] > 1 {
+			metric += fmt.Sprintf(`;desc="%d calls"`, counts[name])
+		}
+		metrics = append(metrics, metric)
+	}
+	metrics = append(metrics, "gw;dur="+formatMillis(max(total-backendTotal, 0)))
+	return strings.Join(metrics, ", ")
+}
+
+// serverTimingName shortens a backend name to a metric name, e.g. "article-service" -> "article"
+func serverTimingName(backend string) string {
+	name := strings.TrimSuffix(backend, "-service")
+	return strings.Map(func(c rune) rune {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9', c == '-', c == '_', c == '.':
+			return c
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+func formatMillis(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d.Microseconds())/1000)
+}
@@ -1,13 +1,33 @@
 package middleware
 
 import (
+	"bufio"
+	"bytes"
 	"context"
+	"encoding/json"
+	"net"
 	"net/http"
+	"sync"
 	"time"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+	"github.com/thatlq1812/service-3-gateway/internal/response"
 )
 
+// timeoutCode is the API code of the answer to a timed out request
+const timeoutCode = "504"
+
 // TimeoutMiddleware adds request timeout to prevent hanging requests
 func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
+	return TimeoutMiddlewareWithHook(timeout, nil)
+}
+
+// TimeoutMiddlewareWithHook is TimeoutMiddleware calling onTimeout for every timed out request.
+// Like http.TimeoutHandler, the handler writes to its own buffer, copied to the client when it
+// finishes in time; after a timeout its writes fail with http.ErrHandlerTimeout and are dropped.
+// A handler that flushes streams from then on, and a timeout only stops its further writes.
+func TimeoutMiddlewareWithHook(timeout time.Duration, onTimeout func(r *http.Request)) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx, cancel := context.WithTimeout(r.Context(), timeout)
@@ -16,23 +36,158 @@ func TimeoutMiddleware(timeout time.Duration) func(http.Handler) http.Handler {
 			// Pass context with timeout to next handler
 			r = r.WithContext(ctx)
 
-			// Channel to signal handler completion
+			tw := &timeoutWriter{w: w, h: w.Header().Clone(), code: http.StatusOK}
 			done := make(chan struct{})
+			panicked := make(chan any, 1)
 			go func() {
-				next.ServeHTTP(w, r)
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, r)
 				close(done)
 			}()
 
 			select {
+			case p := <-panicked:
+				// Re-raised on the serving goroutine, where the server can recover it
+				panic(p)
 			case <-done:
 				// Request completed successfully
-				return
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				if !tw.hijacked && !tw.streaming {
+					tw.commit()
+				}
 			case <-ctx.Done():
 				// Timeout occurred
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.timedOut = true
+				if tw.hijacked {
+					return
+				}
+				if onTimeout != nil {
+					onTimeout(r)
+				}
+				if tw.streaming {
+					// The status is already sent, the client sees a truncated body
+					return
+				}
+				tw.answered = true
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusGatewayTimeout)
-				w.Write([]byte(`{"code":"504","message":"request timeout: service took too long to respond"}`))
+				json.NewEncoder(w).Encode(response.APIResponse{
+					Code:      timeoutCode,
+					Message:   response.Localize(w, i18n.RequestTimeout),
+					RequestID: w.Header().Get(reqctx.RequestIDHeader),
+				})
 			}
 		})
 	}
 }
+
+// timeoutWriter buffers the response of a handler running under TimeoutMiddleware
+type timeoutWriter struct {
+	w http.ResponseWriter
+	h http.Header
+
+	mu          sync.Mutex
+	body        bytes.Buffer
+	code        int
+	wroteHeader bool
+	streaming   bool // Flushed: headers and buffer are sent, writes go to w
+	hijacked    bool
+	timedOut    bool
+	answered    bool // The client got the timeout answer instead of the handler response
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.code = code
+	tw.wroteHeader = true
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.wroteHeader = true
+	if tw.streaming {
+		return tw.w.Write(b)
+	}
+	return tw.body.Write(b)
+}
+
+// Flush sends the buffered response and streams the rest of it
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut || tw.hijacked {
+		return
+	}
+	if !tw.streaming {
+		tw.commit()
+		tw.streaming = true
+	}
+	http.NewResponseController(tw.w).Flush()
+}
+
+// Hijack hands the connection over to the handler, which then owns the response
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	if tw.timedOut {
+		return nil, nil, http.ErrHandlerTimeout
+	}
+	conn, rw, err := http.NewResponseController(tw.w).Hijack()
+	if err == nil {
+		tw.hijacked = true
+	}
+	return conn, rw, err
+}
+
+// commit copies the headers (including deletions), status and buffered body to w.
+// The caller holds mu.
+func (tw *timeoutWriter) commit() {
+	dst := tw.w.Header()
+	for name := range dst {
+		if _, ok := tw.h[name]; !ok {
+			delete(dst, name)
+		}
+	}
+	for name, values := range tw.h {
+		dst[name] = values
+	}
+	tw.w.WriteHeader(tw.code)
+	tw.w.Write(tw.body.Bytes())
+	tw.body.Reset()
+}
+
+// timeoutAnswered reports whether TimeoutMiddleware answered the request written to w
+// with a 504, so the response of the handler behind w never reached the client
+func timeoutAnswered(w http.ResponseWriter) bool {
+	for {
+		switch v := w.(type) {
+		case *timeoutWriter:
+			v.mu.Lock()
+			defer v.mu.Unlock()
+			return v.answered
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = v.Unwrap()
+		default:
+			return false
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+	"github.com/thatlq1812/service-3-gateway/internal/tracing"
+)
+
+// TracingMiddleware starts a server span for every request, continuing the trace
+// of an incoming W3C traceparent header when present
+func TracingMiddleware(tracer *tracing.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if parent, ok := tracing.ParseTraceparent(r.Header.Get(tracing.TraceparentHeader)); ok {
+				parent.TraceState = r.Header.Get(tracing.TracestateHeader)
+				ctx = tracing.ContextWithRemoteSpanContext(ctx, parent)
+			}
+
+			route := RouteTemplate(r)
+			ctx, span := tracer.Start(ctx, r.Method+" "+route, tracing.SpanKindServer)
+			defer span.End()
+
+			rec := newResponseRecorder(w)
+			next.ServeHTTP(rec, r.WithContext(ctx))
+
+			span.SetAttribute("http.request.method", r.Method)
+			span.SetAttribute("http.route", route)
+			span.SetAttribute("url.path", r.URL.Path)
+			span.SetAttribute("http.response.status_code", rec.status)
+			span.SetAttribute("client.address", r.RemoteAddr)
+			if id := reqctx.RequestIDFrom(ctx); id != "" {
+				span.SetAttribute("gateway.request_id", id)
+			}
+			if rec.status >= http.StatusInternalServerError {
+				span.SetStatus(tracing.StatusError, http.StatusText(rec.status))
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/openapi"
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+)
+
+// RequestValidationMiddleware rejects requests whose path parameters, query parameters
+// or JSON body violate the OpenAPI schema of the matched route, before any backend is called.
+// Routes without an operation pass through untouched; only operations declaring a JSON body
+// have it buffered, and bodies larger than maxBodyBytes are rejected.
+func RequestValidationMiddleware(v *openapi.Validator, maxBodyBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := RouteTemplate(r)
+			op := v.Operation(r.Method, route)
+			if op == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Only bodies the operation declares are read, others reach the handler untouched
+			var body []byte
+			if op.JSONBody() {
+				var err error
+				body, err = io.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+				if err != nil {
+					response.BadRequest(w, i18n.ReadRequestBodyFailed)
+					return
+				}
+				if int64(len(body)) > maxBodyBytes {
+					response.ValidationFailed(w, []response.FieldError{
+						response.NewFieldError("body", "maxBytes", i18n.BodyTooLarge, map[string]any{"max": maxBodyBytes}),
+					})
+					return
+				}
+				r.Body = io.NopCloser(bytes.NewReader(body))
+			}
+
+			errs := v.Validate(&openapi.Request{
+				Method:   r.Method,
+				Path:     route,
+				PathVars: mux.Vars(r),
+				Query:    r.URL.Query(),
+				Body:     body,
+			})
+			if len(errs) > 0 {
+				response.ValidationFailed(w, errs)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,267 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+)
+
+// Version is the OpenAPI version of generated documents
+const Version = "3.1.0"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"` // path -> lower-case method -> operation
+	Components Components                       `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how callers authenticate
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Operation is one method of a path
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// Parameter is a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // "path", "query" or "header" (headers are not validated)
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody is the JSON body of an operation
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response is one response of an operation
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType is the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Route documents one route registered on the router
+type Route struct {
+	Method      string
+	Path        string // mux path template, e.g. "/api/v1/users/{id}"
+	OperationID string
+	Summary     string
+	Description string
+	Tags        []string
+	Params      []Parameter // Query parameters, and path parameters that are not plain strings
+	Request     any         // JSON request body type, nil for none
+	Response    any         // Type of APIResponse.Data, or of the whole body when Raw
+	List        bool        // APIResponse.Data is a paginated list of Response items
+	Raw         bool        // Response is the body itself, not wrapped in APIResponse
+	Auth        bool        // Requires a bearer token
+}
+
+// bearerAuth is the security scheme name of bearer token routes
+const bearerAuth = "bearerAuth"
+
+// pathParam matches the variables of a mux path template, with an optional pattern
+var pathParam = regexp.MustCompile(`\{([^}:]+)(?::[^}]*)?\}`)
+
+// Generate builds the document of the routes registered on router.
+// It fails when a registered route has no Route entry, or an entry has no registered route,
+// so that the document cannot drift from the router.
+func Generate(info Info, router *mux.Router, routes []Route) (*Document, error) {
+	registered, err := registeredRoutes(router)
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	documented := make(map[string]bool, len(routes))
+	for _, route := range routes {
+		key := route.Method + " " + route.Path
+		documented[key] = true
+		if !registered[key] {
+			problems = append(problems, "documented route is not registered: "+key)
+		}
+	}
+	for key := range registered {
+		if !documented[key] {
+			problems = append(problems, "registered route has no OpenAPI entry: "+key)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("openapi: %s", strings.Join(problems, "; "))
+	}
+
+	b := &schemaBuilder{components: make(map[string]*Schema)}
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   make(map[string]map[string]*Operation),
+	}
+	for _, route := range routes {
+		path := pathParam.ReplaceAllString(route.Path, "{$1}")
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*Operation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = b.operation(route)
+		if route.Auth {
+			doc.Components.SecuritySchemes = map[string]SecurityScheme{
+				bearerAuth: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			}
+		}
+	}
+	doc.Components.Schemas = b.components
+	return doc, nil
+}
+
+// registeredRoutes returns "METHOD path" of every route with a handler
+func registeredRoutes(router *mux.Router) (map[string]bool, error) {
+	registered := make(map[string]bool)
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		if route.GetHandler() == nil {
+			return nil // Subrouter prefix
+		}
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return fmt.Errorf("openapi: route %s has no methods", path)
+		}
+		for _, method := range methods {
+			registered[method+" "+path] = true
+		}
+		return nil
+	})
+	return registered, err
+}
+
+func (b *schemaBuilder) operation(route Route) *Operation {
+	op := &Operation{
+		OperationID: route.OperationID,
+		Summary:     route.Summary,
+		Description: route.Description,
+		Tags:        route.Tags,
+		Responses:   make(map[string]Response),
+	}
+
+	// Path parameters are required strings unless declared otherwise
+	declared := make(map[string]bool)
+	for _, param := range route.Params {
+		declared[param.In+":"+param.Name] = true
+	}
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		if !declared["path:"+match[1]] {
+			op.Parameters = append(op.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	op.Parameters = append(op.Parameters, route.Params...)
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  jsonContent(b.schemaOf(reflect.TypeOf(route.Request))),
+		}
+	}
+
+	op.Responses["200"] = Response{Description: "Success", Content: jsonContent(b.successSchema(route))}
+	if !route.Raw {
+		content := jsonContent(b.schemaOf(reflect.TypeOf(response.APIResponse{})))
+		content[response.ProblemContentType] = MediaType{Schema: b.schemaOf(reflect.TypeOf(response.Problem{}))}
+		op.Responses["default"] = Response{
+			Description: "Error, `code` is the gRPC status code as a 3-digit string. RFC 7807 problem details when requested with `Accept: application/problem+json`",
+			Content:     content,
+		}
+	}
+	if route.Auth {
+		op.Security = []map[string][]string{{bearerAuth: {}}}
+	}
+	return op
+}
+
+// successSchema is the APIResponse envelope with the route data, or the raw response
+func (b *schemaBuilder) successSchema(route Route) *Schema {
+	var data *Schema
+	if route.Response != nil {
+		data = b.schemaOf(reflect.TypeOf(route.Response))
+	}
+	if route.Raw {
+		if data == nil {
+			return &Schema{}
+		}
+		return data
+	}
+
+	if route.List {
+		list := b.structSchema(reflect.TypeOf(response.ListData{}))
+		list.Properties["items"] = &Schema{Type: "array", Items: data}
+		data = list
+	}
+
+	envelope := &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"code":    {Type: "string", Example: response.CodeOK},
+			"message": {Type: "string", Example: "success"},
+		},
+		Required: []string{"code", "message"},
+	}
+	if data != nil {
+		envelope.Properties["data"] = data
+		envelope.Required = append(envelope.Required, "data")
+	}
+	return envelope
+}
+
+func jsonContent(schema *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: schema}}
+}
+
+// Handler serves the document as JSON
+func Handler(doc *Document) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, doc)
+	})
+}
@@ -0,0 +1,157 @@
+package openapi
+
+import (
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema (2020-12, as used by OpenAPI 3.1)
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 any                `json:"type,omitempty"` // string, or []string with "null"
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Default              any                `json:"default,omitempty"`
+	Example              any                `json:"example,omitempty"`
+}
+
+// Bound returns a pointer to v, for Minimum and Maximum
+func Bound(v float64) *float64 {
+	return &v
+}
+
+// schemaBuilder derives schemas from Go types, collecting named struct types as components
+type schemaBuilder struct {
+	components map[string]*Schema
+}
+
+// schemaOf returns the schema of t; named structs become a $ref to a component
+func (b *schemaBuilder) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == reflect.TypeOf(time.Time{}):
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := b.components[t.Name()]; !ok {
+			b.components[t.Name()] = nil // Reserve the name, recursive types refer to it
+			b.components[t.Name()] = b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + t.Name()}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaOf(t.Elem())}
+	case reflect.Struct:
+		return b.structSchema(t)
+	default:
+		return &Schema{} // interface{}: any value
+	}
+}
+
+// structSchema builds an object schema from the exported fields and their json tags.
+// Fields are required unless tagged omitempty; the openapi tag adds "nullable",
+// "optional" and "format=<format>".
+func (b *schemaBuilder) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.addFields(schema, t)
+	return schema
+}
+
+func (b *schemaBuilder) addFields(schema *Schema, t reflect.Type) {
+	var embedded []reflect.Type
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		// Embedded structs without a json name are flattened, like encoding/json does
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, field.Type)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := b.schemaOf(field.Type)
+		required := !strings.Contains(opts, "omitempty")
+		for _, opt := range strings.Split(field.Tag.Get("openapi"), ",") {
+			switch {
+			case opt == "nullable":
+				prop = nullable(prop)
+			case opt == "optional":
+				required = false
+			case strings.HasPrefix(opt, "format="):
+				prop.Format = strings.TrimPrefix(opt, "format=")
+			}
+		}
+
+		schema.Properties[name] = prop
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	// Fields of embedded structs only fill in names not defined by the outer struct
+	for _, et := range embedded {
+		inner := &Schema{Properties: make(map[string]*Schema)}
+		b.addFields(inner, et)
+		for name, prop := range inner.Properties {
+			if _, shadowed := schema.Properties[name]; !shadowed {
+				schema.Properties[name] = prop
+				if slices.Contains(inner.Required, name) {
+					schema.Required = append(schema.Required, name)
+				}
+			}
+		}
+	}
+	sort.Strings(schema.Required)
+}
+
+// nullable allows null in addition to the schema
+func nullable(s *Schema) *Schema {
+	if s.Ref != "" {
+		return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+	}
+	if typ, ok := s.Type.(string); ok {
+		s.Type = []string{typ, "null"}
+	}
+	return s
+}
@@ -0,0 +1,30 @@
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html/template"
+	"net/http"
+)
+
+//go:embed ui.html
+var uiHTML string
+
+var uiTemplate = template.Must(template.New("ui").Parse(uiHTML))
+
+// UIHandler serves an interactive page to browse and try the operations of the
+// document at specURL. It is self-contained (no CDN), so it also works offline.
+func UIHandler(title, specURL string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		// The page runs its own inline script and only calls this origin
+		w.Header().Set("Content-Security-Policy", "default-src 'self'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; frame-ancestors 'none'")
+		uiTemplate.Execute(w, map[string]string{"Title": title, "SpecURL": specURL})
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
@@ -0,0 +1,385 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/mail"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+)
+
+// Load reads an OpenAPI document from a JSON file
+func Load(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("openapi: %s: %w", path, err)
+	}
+	return &doc, nil
+}
+
+// Validator checks requests against the parameters and request body schemas of a document
+type Validator struct {
+	doc *Document
+
+	mu       sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+// NewValidator returns a validator of the operations of doc
+func NewValidator(doc *Document) *Validator {
+	return &Validator{doc: doc, patterns: make(map[string]*regexp.Regexp)}
+}
+
+// Request is the part of an HTTP request that is validated
+type Request struct {
+	Method   string
+	Path     string // mux path template of the matched route
+	PathVars map[string]string
+	Query    map[string][]string
+	Body     []byte
+}
+
+// Operation returns the operation of a method and mux path template, nil if the document has none
+func (v *Validator) Operation(method, path string) *Operation {
+	return v.doc.Paths[pathParam.ReplaceAllString(path, "{$1}")][strings.ToLower(method)]
+}
+
+// JSONBody reports whether the operation declares a JSON request body
+func (op *Operation) JSONBody() bool {
+	if op == nil || op.RequestBody == nil {
+		return false
+	}
+	_, ok := op.RequestBody.Content["application/json"]
+	return ok
+}
+
+// Validate returns every violation of the operation's parameter and body schemas.
+// Requests of operations missing from the document are not checked.
+func (v *Validator) Validate(req *Request) []response.FieldError {
+	op := v.Operation(req.Method, req.Path)
+	if op == nil {
+		return nil
+	}
+
+	var errs []response.FieldError
+	for _, param := range op.Parameters {
+		var value string
+		var present bool
+		switch param.In {
+		case "path":
+			value, present = req.PathVars[param.Name]
+		case "query":
+			if values := req.Query[param.Name]; len(values) > 0 {
+				value, present = values[0], true
+			}
+		default:
+			continue
+		}
+
+		if !present || value == "" {
+			if param.Required {
+				errs = append(errs, fieldError(param.Name, "required", i18n.FieldRequired, nil))
+			}
+			continue
+		}
+		parsed, invalid := parseParam(value, v.resolve(param.Schema))
+		if invalid != "" {
+			errs = append(errs, typeError(param.Name, []string{invalid}))
+			continue
+		}
+		errs = v.check(errs, param.Name, param.Schema, parsed)
+	}
+
+	if op.RequestBody != nil {
+		errs = v.validateBody(errs, op.RequestBody, req.Body)
+	}
+	return errs
+}
+
+func (v *Validator) validateBody(errs []response.FieldError, body *RequestBody, data []byte) []response.FieldError {
+	media, ok := body.Content["application/json"]
+	if !ok {
+		return errs
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			errs = append(errs, fieldError("body", "required", i18n.BodyRequired, nil))
+		}
+		return errs
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return append(errs, fieldError("body", "json", i18n.BodyInvalidJSON, nil))
+	}
+	return v.check(errs, "", media.Schema, value)
+}
+
+// check validates a decoded JSON value (nil, bool, json.Number, string, []any, map[string]any)
+func (v *Validator) check(errs []response.FieldError, field string, schema *Schema, value any) []response.FieldError {
+	schema = v.resolve(schema)
+	if schema == nil {
+		return errs
+	}
+	name := field
+	if name == "" {
+		name = "body"
+	}
+
+	if len(schema.AnyOf) > 0 {
+		for _, alt := range schema.AnyOf {
+			if len(v.check(nil, field, alt, value)) == 0 {
+				return errs
+			}
+		}
+		// Report the violations of the first non-null alternative
+		for _, alt := range schema.AnyOf {
+			if !slices.Contains(schemaTypes(v.resolve(alt)), "null") {
+				return v.check(errs, field, alt, value)
+			}
+		}
+		return errs
+	}
+
+	if types := schemaTypes(schema); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasType(value, t) }) {
+		return append(errs, typeError(name, types))
+	}
+
+	if len(schema.Enum) > 0 && !slices.ContainsFunc(schema.Enum, func(e any) bool { return fmt.Sprint(e) == fmt.Sprint(value) }) {
+		allowed := make([]string, len(schema.Enum))
+		for i, e := range schema.Enum {
+			allowed[i] = fmt.Sprint(e)
+		}
+		errs = append(errs, fieldError(name, "enum", i18n.FieldEnum, map[string]any{"values": strings.Join(allowed, ", ")}))
+	}
+
+	switch value := value.(type) {
+	case json.Number:
+		n, _ := value.Float64()
+		if schema.Minimum != nil && n < *schema.Minimum {
+			errs = append(errs, fieldError(name, "minimum", i18n.FieldMinimum, map[string]any{"min": formatNumber(*schema.Minimum)}))
+		}
+		if schema.Maximum != nil && n > *schema.Maximum {
+			errs = append(errs, fieldError(name, "maximum", i18n.FieldMaximum, map[string]any{"max": formatNumber(*schema.Maximum)}))
+		}
+		if schema.Format == "int32" && (n < math.MinInt32 || n > math.MaxInt32) {
+			errs = append(errs, fieldError(name, "format", i18n.FieldInt32, nil))
+		}
+
+	case string:
+		length := utf8.RuneCountInString(value)
+		if schema.MinLength != nil && length < *schema.MinLength {
+			errs = append(errs, fieldError(name, "minLength", i18n.FieldMinLength, map[string]any{"min": *schema.MinLength}))
+		}
+		if schema.MaxLength != nil && length > *schema.MaxLength {
+			errs = append(errs, fieldError(name, "maxLength", i18n.FieldMaxLength, map[string]any{"max": *schema.MaxLength}))
+		}
+		if schema.Pattern != "" {
+			if re := v.pattern(schema.Pattern); re != nil && !re.MatchString(value) {
+				errs = append(errs, fieldError(name, "pattern", i18n.FieldPattern, map[string]any{"pattern": schema.Pattern}))
+			}
+		}
+		if key := checkFormat(schema.Format, value); key != "" {
+			errs = append(errs, fieldError(name, "format", key, nil))
+		}
+
+	case []any:
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			errs = append(errs, fieldError(name, "minItems", i18n.FieldMinItems, map[string]any{"min": *schema.MinItems}))
+		}
+		if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+			errs = append(errs, fieldError(name, "maxItems", i18n.FieldMaxItems, map[string]any{"max": *schema.MaxItems}))
+		}
+		for i, item := range value {
+			errs = v.check(errs, fmt.Sprintf("%s[%d]", name, i), schema.Items, item)
+		}
+
+	case map[string]any:
+		for _, required := range schema.Required {
+			if _, ok := value[required]; !ok {
+				errs = append(errs, fieldError(join(field, required), "required", i18n.FieldRequired, nil))
+			}
+		}
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if prop, ok := schema.Properties[key]; ok {
+				errs = v.check(errs, join(field, key), prop, value[key])
+			} else if schema.AdditionalProperties != nil {
+				errs = v.check(errs, join(field, key), schema.AdditionalProperties, value[key])
+			}
+		}
+	}
+	return errs
+}
+
+// resolve follows $ref to a component schema
+func (v *Validator) resolve(schema *Schema) *Schema {
+	for i := 0; schema != nil && schema.Ref != "" && i < 32; i++ {
+		schema = v.doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+// pattern compiles and caches a schema pattern; invalid patterns are ignored
+func (v *Validator) pattern(expr string) *regexp.Regexp {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	re, ok := v.patterns[expr]
+	if !ok {
+		re, _ = regexp.Compile(expr)
+		v.patterns[expr] = re
+	}
+	return re
+}
+
+// parseParam converts a path or query string to the JSON value of its schema type,
+// or returns the type the string is not of
+func parseParam(value string, schema *Schema) (any, string) {
+	types := schemaTypes(schema)
+	switch {
+	case slices.Contains(types, "integer"):
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, "integer"
+		}
+		return json.Number(value), ""
+	case slices.Contains(types, "number"):
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, "number"
+		}
+		return json.Number(value), ""
+	case slices.Contains(types, "boolean"):
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, "boolean"
+		}
+		return b, ""
+	default:
+		return value, ""
+	}
+}
+
+// schemaTypes returns the allowed types of a schema, whether declared as a string or a list
+func schemaTypes(schema *Schema) []string {
+	if schema == nil {
+		return nil
+	}
+	switch t := schema.Type.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func hasType(value any, typ string) bool {
+	switch typ {
+	case "null":
+		return value == nil
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+		_, err := strconv.ParseInt(string(n), 10, 64)
+		return err == nil
+	case "number":
+		_, ok := value.(json.Number)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	}
+	return true
+}
+
+// checkFormat validates the string formats the gateway relies on, others are not checked.
+// It returns the message key of the violation, or "".
+func checkFormat(format, value string) string {
+	switch format {
+	case "email":
+		// Surrounding whitespace is trimmed later by the payload validation
+		value = strings.TrimSpace(value)
+		if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+			return i18n.FieldEmail
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return i18n.FieldDateTime
+		}
+	}
+	return ""
+}
+
+func fieldError(field, rule, key string, params map[string]any) response.FieldError {
+	return response.NewFieldError(field, rule, key, params)
+}
+
+// typeKeys are the message keys of a value not of a single schema type
+var typeKeys = map[string]string{
+	"integer": i18n.FieldTypeInteger,
+	"number":  i18n.FieldTypeNumber,
+	"boolean": i18n.FieldTypeBoolean,
+	"string":  i18n.FieldTypeString,
+	"array":   i18n.FieldTypeArray,
+	"object":  i18n.FieldTypeObject,
+	"null":    i18n.FieldTypeNull,
+}
+
+// typeError is the violation of a value of none of the schema types
+func typeError(field string, types []string) response.FieldError {
+	if key, ok := typeKeys[types[0]]; ok && len(types) == 1 {
+		return fieldError(field, "type", key, nil)
+	}
+	return fieldError(field, "type", i18n.FieldType, map[string]any{"types": strings.Join(types, ", ")})
+}
+
+func join(parent, field string) string {
+	if parent == "" {
+		return field
+	}
+	return parent + "." + field
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
@@ -0,0 +1,81 @@
+package reqctx
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Call is one backend RPC made while serving a request
+type Call struct {
+	Backend  string // Backend service name, e.g. "user-service"
+	Method   string // gRPC method, e.g. "GetUser"
+	Code     string // gRPC status code, e.g. "OK"
+	Duration time.Duration
+}
+
+// Record collects what inner layers learn while a request is served (principal,
+// backend calls), so that outer middlewares such as logging can report it afterwards.
+// All methods are safe on a nil Record.
+type Record struct {
+	mu        sync.Mutex
+	principal Principal
+	calls     []Call
+}
+
+type recordKey struct{}
+
+// WithRecord returns a copy of ctx carrying a new, empty request record
+func WithRecord(ctx context.Context) (context.Context, *Record) {
+	rec := &Record{}
+	return context.WithValue(ctx, recordKey{}, rec), rec
+}
+
+// RecordFrom returns the request record, or nil outside of a recorded request
+func RecordFrom(ctx context.Context) *Record {
+	rec, _ := ctx.Value(recordKey{}).(*Record)
+	return rec
+}
+
+// Principal returns the principal set during the request, if any
+func (rec *Record) Principal() (Principal, bool) {
+	if rec == nil {
+		return Principal{}, false
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.principal, rec.principal.Name != ""
+}
+
+// AddCall records a finished backend call
+func (rec *Record) AddCall(call Call) {
+	if rec == nil {
+		return
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.calls = append(rec.calls, call)
+}
+
+// Calls returns the backend calls made so far, in completion order
+func (rec *Record) Calls() []Call {
+	if rec == nil {
+		return nil
+	}
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return append([]Call(nil), rec.calls...)
+}
+
+// Backends returns the distinct backends called so far, in first call order
+func (rec *Record) Backends() []string {
+	var backends []string
+	seen := make(map[string]bool)
+	for _, call := range rec.Calls() {
+		if !seen[call.Backend] {
+			seen[call.Backend] = true
+			backends = append(backends, call.Backend)
+		}
+	}
+	return backends
+}
@@ -0,0 +1,48 @@
+package reqctx
+
+import "context"
+
+// Principal identifies the authenticated caller of a request
+type Principal struct {
+	Name    string // Mapped principal name used for authorization and logging
+	Source  string // How the caller was authenticated, e.g. "mtls"
+	Subject string // Raw subject the principal was derived from
+}
+
+type principalKey struct{}
+
+// WithPrincipal returns a copy of ctx carrying the request principal.
+// The principal is also stored in the request record, if any.
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	if rec := RecordFrom(ctx); rec != nil {
+		rec.mu.Lock()
+		rec.principal = p
+		rec.mu.Unlock()
+	}
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// PrincipalFrom returns the request principal, if the caller was authenticated
+func PrincipalFrom(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// RequestIDHeader carries the request ID on HTTP requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMetadataKey carries the request ID in gRPC metadata sent to backends
+const RequestIDMetadataKey = "x-request-id"
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFrom returns the request ID, or "" outside of a request
+func RequestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
@@ -0,0 +1,107 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"sync/atomic"
+
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// CodeInfo is how an API code, in-band resp.Code or converted gRPC status, is surfaced
+type CodeInfo struct {
+	HTTPStatus int    `json:"http_status"`
+	Name       string `json:"name"` // CamelCase, e.g. "NotFound", used for problem type and title
+}
+
+// StatusUnknownCode is the HTTP status of codes missing from the table:
+// the backend answered, but with something the gateway cannot interpret
+const StatusUnknownCode = http.StatusBadGateway
+
+// DefaultCodes returns the table of the gRPC status codes
+func DefaultCodes() map[string]CodeInfo {
+	return map[string]CodeInfo{
+		CodeOK:                 {http.StatusOK, "OK"},
+		CodeCancelled:          {499, "Canceled"}, // Client Closed Request
+		CodeUnknown:            {http.StatusInternalServerError, "Unknown"},
+		CodeInvalidArgument:    {http.StatusBadRequest, "InvalidArgument"},
+		CodeDeadlineExceeded:   {http.StatusGatewayTimeout, "DeadlineExceeded"},
+		CodeNotFound:           {http.StatusNotFound, "NotFound"},
+		CodeAlreadyExists:      {http.StatusConflict, "AlreadyExists"},
+		CodePermissionDenied:   {http.StatusForbidden, "PermissionDenied"},
+		CodeResourceExhausted:  {http.StatusTooManyRequests, "ResourceExhausted"},
+		CodeFailedPrecondition: {http.StatusBadRequest, "FailedPrecondition"},
+		CodeAborted:            {http.StatusConflict, "Aborted"},
+		CodeOutOfRange:         {http.StatusBadRequest, "OutOfRange"},
+		CodeUnimplemented:      {http.StatusNotImplemented, "Unimplemented"},
+		CodeInternal:           {http.StatusInternalServerError, "Internal"},
+		CodeUnavailable:        {http.StatusServiceUnavailable, "Unavailable"},
+		CodeDataLoss:           {http.StatusInternalServerError, "DataLoss"},
+		CodeUnauthenticated:    {http.StatusUnauthorized, "Unauthenticated"},
+	}
+}
+
+// LoadCodes reads a JSON object of code -> {http_status, name} over the defaults.
+// A missing name keeps the default one, so existing codes can be remapped by status only.
+func LoadCodes(path string) (map[string]CodeInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("response codes: %w", err)
+	}
+	var overrides map[string]CodeInfo
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("response codes: %s: %w", path, err)
+	}
+
+	table := DefaultCodes()
+	for code, info := range overrides {
+		if code == "" {
+			return nil, fmt.Errorf("response codes: empty code")
+		}
+		if info.HTTPStatus < 100 || info.HTTPStatus > 599 {
+			return nil, fmt.Errorf("response codes: %s: invalid http_status %d", code, info.HTTPStatus)
+		}
+		if info.Name == "" {
+			info.Name = table[code].Name
+		}
+		table[code] = info
+	}
+	return table, nil
+}
+
+var codeTable atomic.Pointer[map[string]CodeInfo]
+
+// SetCodes replaces the code table used by every error response
+func SetCodes(table map[string]CodeInfo) {
+	table = maps.Clone(table)
+	codeTable.Store(&table)
+}
+
+// LookupCode returns the table entry of an API code
+func LookupCode(code string) (CodeInfo, bool) {
+	if table := codeTable.Load(); table != nil {
+		info, ok := (*table)[code]
+		return info, ok
+	}
+	info, ok := defaultCodes[code]
+	return info, ok
+}
+
+var defaultCodes = DefaultCodes()
+
+// codeStatus returns the HTTP status of a code, logging codes missing from the table
+func codeStatus(w http.ResponseWriter, code, message string) int {
+	if info, ok := LookupCode(code); ok {
+		return info.HTTPStatus
+	}
+	slog.Warn("Unknown backend response code",
+		"code", code,
+		"message", message,
+		"request_id", w.Header().Get(reqctx.RequestIDHeader),
+	)
+	return StatusUnknownCode
+}
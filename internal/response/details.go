@@ -0,0 +1,99 @@
+package response
+
+import (
+	"math"
+	"net/http"
+	"reflect"
+	"strconv"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorDetails are the google.rpc error details attached to a backend status.
+// DebugInfo is dropped, stack traces of the backends are not for clients.
+type ErrorDetails struct {
+	Errors        []FieldError      `json:"errors,omitempty"`        // BadRequest field violations
+	Reason        string            `json:"reason,omitempty"`        // ErrorInfo
+	Domain        string            `json:"domain,omitempty"`        // ErrorInfo
+	Metadata      map[string]string `json:"metadata,omitempty"`      // ErrorInfo
+	RetryAfter    int64             `json:"retry_after,omitempty"`   // RetryInfo, in seconds
+	Preconditions []Violation       `json:"preconditions,omitempty"` // PreconditionFailure
+	Quota         []Violation       `json:"quota,omitempty"`         // QuotaFailure
+	Resource      *ResourceInfo     `json:"resource,omitempty"`      // ResourceInfo
+	Help          []HelpLink        `json:"help,omitempty"`          // Help
+}
+
+// Violation is one precondition or quota failure
+type Violation struct {
+	Type        string `json:"type,omitempty"`
+	Subject     string `json:"subject,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// ResourceInfo describes the resource the error is about
+type ResourceInfo struct {
+	Type        string `json:"type,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// HelpLink points to documentation about the error
+type HelpLink struct {
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+// DecodeDetails maps the known details of a gRPC status, returns nil if there are none
+func DecodeDetails(st *status.Status) *ErrorDetails {
+	var d ErrorDetails
+	for _, detail := range st.Details() {
+		switch detail := detail.(type) {
+		case *errdetails.BadRequest:
+			for _, v := range detail.GetFieldViolations() {
+				rule := v.GetReason()
+				if rule == "" {
+					rule = "invalid"
+				}
+				d.Errors = append(d.Errors, FieldError{Field: v.GetField(), Rule: rule, Message: v.GetDescription()})
+			}
+		case *errdetails.ErrorInfo:
+			d.Reason, d.Domain, d.Metadata = detail.GetReason(), detail.GetDomain(), detail.GetMetadata()
+		case *errdetails.RetryInfo:
+			if delay := detail.GetRetryDelay(); delay != nil {
+				d.RetryAfter = int64(math.Ceil(delay.AsDuration().Seconds()))
+			}
+		case *errdetails.PreconditionFailure:
+			for _, v := range detail.GetViolations() {
+				d.Preconditions = append(d.Preconditions, Violation{Type: v.GetType(), Subject: v.GetSubject(), Description: v.GetDescription()})
+			}
+		case *errdetails.QuotaFailure:
+			for _, v := range detail.GetViolations() {
+				d.Quota = append(d.Quota, Violation{Subject: v.GetSubject(), Description: v.GetDescription()})
+			}
+		case *errdetails.ResourceInfo:
+			d.Resource = &ResourceInfo{
+				Type:        detail.GetResourceType(),
+				Name:        detail.GetResourceName(),
+				Owner:       detail.GetOwner(),
+				Description: detail.GetDescription(),
+			}
+		case *errdetails.Help:
+			for _, link := range detail.GetLinks() {
+				d.Help = append(d.Help, HelpLink{Description: link.GetDescription(), URL: link.GetUrl()})
+			}
+		}
+	}
+	if reflect.ValueOf(d).IsZero() {
+		return nil
+	}
+	return &d
+}
+
+// setRetryAfter sets the Retry-After header from RetryInfo
+func setRetryAfter(w http.ResponseWriter, details *ErrorDetails) {
+	if details != nil && details.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.FormatInt(details.RetryAfter, 10))
+	}
+}
@@ -0,0 +1,87 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+const ProblemContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object, with the gateway code,
+// the request ID and the decoded error details as extension members
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"request_id,omitempty"`
+	ErrorDetails
+}
+
+// NewProblem converts an error envelope written with the given HTTP status.
+// With an empty typeBase the type is "about:blank" and the title the HTTP status text,
+// otherwise the type is typeBase followed by the code name, e.g. ".../not-found".
+func NewProblem(httpStatus int, resp APIResponse, typeBase, instance string) Problem {
+	p := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(httpStatus),
+		Status:    httpStatus,
+		Detail:    resp.Message,
+		Instance:  instance,
+		Code:      resp.Code,
+		RequestID: resp.RequestID,
+	}
+	if name := codeName(resp.Code); typeBase != "" && name != "" {
+		p.Type = typeBase + strings.ToLower(strings.Join(splitWords(name), "-"))
+		p.Title = strings.Join(splitWords(name), " ")
+	}
+
+	switch data := resp.Data.(type) {
+	case *ErrorDetails:
+		p.ErrorDetails = *data
+	case []FieldError:
+		p.Errors = data
+	case json.RawMessage:
+		// Envelope read back from a response body
+		data = bytes.TrimSpace(data)
+		if len(data) > 0 && data[0] == '[' {
+			json.Unmarshal(data, &p.Errors)
+		} else if len(data) > 0 && data[0] == '{' {
+			json.Unmarshal(data, &p.ErrorDetails)
+		}
+	}
+	return p
+}
+
+// WriteProblem writes p as application/problem+json
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// codeName returns the code table name of an API code, e.g. "NotFound" for "005"
+func codeName(code string) string {
+	info, _ := LookupCode(code)
+	return info.Name
+}
+
+// splitWords splits a CamelCase name: "InvalidArgument" -> ["Invalid", "Argument"]
+func splitWords(name string) []string {
+	var words []string
+	start := 0
+	for i, c := range name {
+		if i > 0 && unicode.IsUpper(c) {
+			words = append(words, name[start:i])
+			start = i
+		}
+	}
+	return append(words, name[start:])
+}
@@ -0,0 +1,68 @@
+package response
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
+)
+
+// FieldError is one violation of a request validation rule
+type FieldError struct {
+	Field   string `json:"field"`   // Parameter or JSON body field, e.g. "page_size" or "user.email"
+	Rule    string `json:"rule"`    // Violated rule, e.g. "required", "type", "maximum"
+	Message string `json:"message"` // Human readable explanation
+	Key     string `json:"-"`       // Catalog key of Message, localized by ValidationFailed
+	// Values of the {name} placeholders of Key. A []string value lists catalog keys,
+	// each formatted with the other values and joined with ", "
+	Params map[string]any `json:"-"`
+}
+
+// NewFieldError returns the violation of rule by field, with Message in the fallback language
+func NewFieldError(field, rule, key string, params map[string]any) FieldError {
+	e := FieldError{Field: field, Rule: rule, Key: key, Params: params}
+	e.Message = e.localize("")
+	return e
+}
+
+// localize formats the message of the field error in lang
+func (e FieldError) localize(lang string) string {
+	values := make(map[string]string, len(e.Params))
+	for name, value := range e.Params {
+		if _, ok := value.([]string); !ok {
+			values[name] = fmt.Sprint(value)
+		}
+	}
+	for name, value := range e.Params {
+		if keys, ok := value.([]string); ok {
+			parts := make([]string, len(keys))
+			for i, key := range keys {
+				parts[i] = i18n.Format(lang, key, values)
+			}
+			values[name] = strings.Join(parts, ", ")
+		}
+	}
+	return i18n.Format(lang, e.Key, values)
+}
+
+// ValidationFailed returns invalid argument error (code "3") with every violation in data,
+// messages of violations with a catalog key in the response language
+func ValidationFailed(w http.ResponseWriter, errs []FieldError) {
+	lang := w.Header().Get("Content-Language")
+	for i := range errs {
+		if errs[i].Key != "" {
+			errs[i].Message = errs[i].localize(lang)
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	json.NewEncoder(w).Encode(APIResponse{
+		Code:      CodeInvalidArgument,
+		Message:   Localize(w, i18n.RequestValidationFailed),
+		Data:      errs,
+		RequestID: w.Header().Get(reqctx.RequestIDHeader),
+	})
+}
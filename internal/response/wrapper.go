@@ -6,13 +6,17 @@ import (
 
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/reqctx"
 )
 
 // APIResponse standard format
 type APIResponse struct {
-	Code    string      `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data,omitempty"`
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Data      interface{} `json:"data,omitempty"`
+	RequestID string      `json:"request_id,omitempty"` // Set on errors, for correlation with gateway and backend logs
 }
 
 // ListData for paginated responses
@@ -88,46 +92,12 @@ func MapGRPCCodeToString(code codes.Code) string {
 	}
 }
 
-// MapGRPCCodeToHTTPStatus converts gRPC code to HTTP status code
+// MapGRPCCodeToHTTPStatus converts gRPC code to HTTP status code, through the code table
 func MapGRPCCodeToHTTPStatus(code codes.Code) int {
-	switch code {
-	case codes.OK:
-		return http.StatusOK
-	case codes.Canceled:
-		return 499 // Client Closed Request
-	case codes.Unknown:
-		return http.StatusInternalServerError
-	case codes.InvalidArgument:
-		return http.StatusBadRequest
-	case codes.DeadlineExceeded:
-		return http.StatusGatewayTimeout
-	case codes.NotFound:
-		return http.StatusNotFound
-	case codes.AlreadyExists:
-		return http.StatusConflict
-	case codes.PermissionDenied:
-		return http.StatusForbidden
-	case codes.ResourceExhausted:
-		return http.StatusTooManyRequests
-	case codes.FailedPrecondition:
-		return http.StatusBadRequest
-	case codes.Aborted:
-		return http.StatusConflict
-	case codes.OutOfRange:
-		return http.StatusBadRequest
-	case codes.Unimplemented:
-		return http.StatusNotImplemented
-	case codes.Internal:
-		return http.StatusInternalServerError
-	case codes.Unavailable:
-		return http.StatusServiceUnavailable
-	case codes.DataLoss:
-		return http.StatusInternalServerError
-	case codes.Unauthenticated:
-		return http.StatusUnauthorized
-	default:
-		return http.StatusInternalServerError
+	if info, ok := LookupCode(MapGRPCCodeToString(code)); ok {
+		return info.HTTPStatus
 	}
+	return http.StatusInternalServerError
 }
 
 // Success returns success response với code "0"
@@ -136,7 +106,7 @@ func Success(w http.ResponseWriter, data interface{}) {
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(APIResponse{
 		Code:    CodeOK,
-		Message: "success",
+		Message: Localize(w, i18n.Success),
 		Data:    data,
 	})
 }
@@ -148,7 +118,7 @@ func SuccessList(w http.ResponseWriter, items interface{}, total int64, page, si
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(APIResponse{
 		Code:    CodeOK,
-		Message: "success",
+		Message: Localize(w, i18n.Success),
 		Data: ListData{
 			Items:   items,
 			Total:   total,
@@ -159,18 +129,31 @@ func SuccessList(w http.ResponseWriter, items interface{}, total int64, page, si
 	})
 }
 
+// Localize returns the catalog text of a gateway message key in the response language
+// (Content-Language set by LocaleMiddleware), or the message itself if it is not a key.
+// Backend messages are never localized.
+func Localize(w http.ResponseWriter, key string) string {
+	return i18n.Message(w.Header().Get("Content-Language"), key)
+}
+
+// writeError writes an error response carrying the request ID set by RequestIDMiddleware
+func writeError(w http.ResponseWriter, httpStatus int, code string, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	json.NewEncoder(w).Encode(APIResponse{
+		Code:      code,
+		Message:   message,
+		RequestID: w.Header().Get(reqctx.RequestIDHeader),
+	})
+}
+
 // Error converts gRPC error to API error response
 // Đây là hàm chính để convert từ gRPC status sang format mentor yêu cầu
 func Error(w http.ResponseWriter, err error) {
 	st, ok := status.FromError(err)
 	if !ok {
 		// Not a gRPC error - treat as internal error
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusInternalServerError)
-		json.NewEncoder(w).Encode(APIResponse{
-			Code:    CodeInternal,
-			Message: err.Error(),
-		})
+		writeError(w, http.StatusInternalServerError, CodeInternal, err.Error())
 		return
 	}
 
@@ -178,73 +161,70 @@ func Error(w http.ResponseWriter, err error) {
 	apiCode := MapGRPCCodeToString(st.Code())
 	httpStatus := MapGRPCCodeToHTTPStatus(st.Code())
 
+	// Keep the google.rpc details the backend attached
+	details := DecodeDetails(st)
+	if details == nil {
+		writeError(w, httpStatus, apiCode, st.Message())
+		return
+	}
+	setRetryAfter(w, details)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(httpStatus)
 	json.NewEncoder(w).Encode(APIResponse{
-		Code:    apiCode,
-		Message: st.Message(),
+		Code:      apiCode,
+		Message:   st.Message(),
+		Data:      details,
+		RequestID: w.Header().Get(reqctx.RequestIDHeader),
 	})
 }
 
 // BadRequest returns invalid argument error (code "3")
 func BadRequest(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusBadRequest)
-	json.NewEncoder(w).Encode(APIResponse{
-		Code:    CodeInvalidArgument,
-		Message: message,
-	})
+	writeError(w, http.StatusBadRequest, CodeInvalidArgument, Localize(w, message))
 }
 
 // Unauthorized returns unauthenticated error (code "16")
 func Unauthorized(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusUnauthorized)
-	json.NewEncoder(w).Encode(APIResponse{
-		Code:    CodeUnauthenticated,
-		Message: message,
-	})
+	writeError(w, http.StatusUnauthorized, CodeUnauthenticated, Localize(w, message))
 }
 
 // NotFound returns not found error (code "5")
 func NotFound(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusNotFound)
-	json.NewEncoder(w).Encode(APIResponse{
-		Code:    CodeNotFound,
-		Message: message,
-	})
+	writeError(w, http.StatusNotFound, CodeNotFound, Localize(w, message))
 }
 
 // ServiceUnavailable returns service unavailable error (code "14")
 // Used when circuit breaker is open or service is down
 func ServiceUnavailable(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusServiceUnavailable)
-	json.NewEncoder(w).Encode(APIResponse{
-		Code:    CodeUnavailable,
-		Message: message,
-	})
+	writeError(w, http.StatusServiceUnavailable, CodeUnavailable, Localize(w, message))
 }
 
 // InternalError returns internal error (code "13")
 func InternalError(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusInternalServerError)
-	json.NewEncoder(w).Encode(APIResponse{
-		Code:    CodeInternal,
-		Message: message,
-	})
+	writeError(w, http.StatusInternalServerError, CodeInternal, Localize(w, message))
+}
+
+// PreconditionFailed returns failed precondition error (code "9") with 412,
+// used when If-Match does not match the current version
+func PreconditionFailed(w http.ResponseWriter, message string) {
+	writeError(w, http.StatusPreconditionFailed, CodeFailedPrecondition, Localize(w, message))
+}
+
+// Conflict returns aborted error (code "10") with 409,
+// used when a request with the same Idempotency-Key is still in flight
+func Conflict(w http.ResponseWriter, message string) {
+	writeError(w, http.StatusConflict, CodeAborted, Localize(w, message))
+}
+
+// UnprocessableEntity returns invalid argument error (code "3") with 422,
+// used when an Idempotency-Key is reused with a different request
+func UnprocessableEntity(w http.ResponseWriter, message string) {
+	writeError(w, http.StatusUnprocessableEntity, CodeInvalidArgument, Localize(w, message))
 }
 
 // Forbidden returns permission denied error (code "7")
 func Forbidden(w http.ResponseWriter, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusForbidden)
-	json.NewEncoder(w).Encode(APIResponse{
-		Code:    CodePermissionDenied,
-		Message: message,
-	})
+	writeError(w, http.StatusForbidden, CodePermissionDenied, Localize(w, message))
 }
 
 // GRPCError converts gRPC code and message to API error response
@@ -252,41 +232,11 @@ func GRPCError(w http.ResponseWriter, code codes.Code, message string) {
 	apiCode := MapGRPCCodeToString(code)
 	httpStatus := MapGRPCCodeToHTTPStatus(code)
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(httpStatus)
-	json.NewEncoder(w).Encode(APIResponse{
-		Code:    apiCode,
-		Message: message,
-	})
+	writeError(w, httpStatus, apiCode, message)
 }
 
-// CustomError returns custom error with specific code
+// CustomError returns custom error with specific code, e.g. an in-band resp.Code.
+// Codes missing from the code table are logged and returned with 502 Bad Gateway.
 func CustomError(w http.ResponseWriter, code string, message string) {
-	// Map string code to HTTP status
-	var httpStatus int
-	switch code {
-	case CodeOK:
-		httpStatus = http.StatusOK
-	case CodeInvalidArgument:
-		httpStatus = http.StatusBadRequest
-	case CodeNotFound:
-		httpStatus = http.StatusNotFound
-	case CodeAlreadyExists:
-		httpStatus = http.StatusConflict
-	case CodePermissionDenied:
-		httpStatus = http.StatusForbidden
-	case CodeUnauthenticated:
-		httpStatus = http.StatusUnauthorized
-	case CodeUnavailable:
-		httpStatus = http.StatusServiceUnavailable
-	default:
-		httpStatus = http.StatusInternalServerError
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(httpStatus)
-	json.NewEncoder(w).Encode(APIResponse{
-		Code:    code,
-		Message: message,
-	})
+	writeError(w, codeStatus(w, code, message), code, message)
 }
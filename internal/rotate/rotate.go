@@ -0,0 +1,301 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat names rotated files so they sort chronologically, e.g.
+// audit.jsonl -> audit-2025-01-15T10-20-30.123.jsonl
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// compressSuffix is appended to rotated files compressed with gzip
+const compressSuffix = ".gz"
+
+// Config controls when a Writer rotates its file and how long rotated files are kept
+type Config struct {
+	Path       string        // Current file, rotated files are written next to it
+	MaxSize    int64         // Rotate before a write would exceed this size in bytes, 0 disables
+	Interval   time.Duration // Rotate on the first write after each interval boundary (aligned to UTC), 0 disables
+	Compress   bool          // Gzip rotated files in the background
+	MaxBackups int           // Rotated files kept, oldest removed first, 0 keeps all
+	MaxAge     time.Duration // Rotated files older than this are removed, 0 keeps all
+}
+
+// Writer is an append-only file writer that rotates the file by size and time.
+// It is safe for concurrent use; every Write is written whole to one file.
+type Writer struct {
+	cfg Config
+
+	mu           sync.Mutex
+	file         *os.File
+	size         int64
+	nextRotation time.Time
+
+	// Compression and retention run in the background, one pass at a time
+	cleanupMu sync.Mutex
+	cleanupWG sync.WaitGroup
+}
+
+// Open opens (or creates) the file for appending
+func Open(cfg Config) (*Writer, error) {
+	if cfg.Path == "" {
+		return nil, fmt.Errorf("rotate: empty path")
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o755); err != nil {
+		return nil, fmt.Errorf("rotate: %w", err)
+	}
+
+	w := &Writer{cfg: cfg}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) open() error {
+	f, err := os.OpenFile(w.cfg.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("rotate: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("rotate: %w", err)
+	}
+	w.file = f
+	w.size = info.Size()
+
+	// An existing file written before the current interval is rotated on the next write
+	if w.cfg.Interval > 0 {
+		opened := time.Now()
+		if w.size > 0 {
+			opened = info.ModTime()
+		}
+		w.nextRotation = opened.Truncate(w.cfg.Interval).Add(w.cfg.Interval)
+	}
+	return nil
+}
+
+// Write appends p, rotating first when the interval has passed or p would not fit in the current file
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.file == nil {
+		return 0, os.ErrClosed
+	}
+	if w.size > 0 && w.dueForRotation(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.file.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *Writer) dueForRotation(n int) bool {
+	if w.cfg.MaxSize > 0 && w.size+int64(n) > w.cfg.MaxSize {
+		return true
+	}
+	return w.cfg.Interval > 0 && !time.Now().Before(w.nextRotation)
+}
+
+// Sync commits the current file to stable storage
+func (w *Writer) Sync() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.file.Sync()
+}
+
+// Rotate closes the current file, renames it to a timestamped backup and starts a new one
+func (w *Writer) Rotate() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.file == nil {
+		return os.ErrClosed
+	}
+	return w.rotate()
+}
+
+func (w *Writer) rotate() error {
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("rotate: %w", err)
+	}
+	w.file = nil
+
+	// Names have millisecond resolution, move forward until unused so a backup is never overwritten
+	t := time.Now()
+	for w.backupExists(BackupName(w.cfg.Path, t)) {
+		t = t.Add(time.Millisecond)
+	}
+	backup := BackupName(w.cfg.Path, t)
+	if err := os.Rename(w.cfg.Path, backup); err != nil {
+		return fmt.Errorf("rotate: %w", err)
+	}
+	if err := w.open(); err != nil {
+		return err
+	}
+
+	if w.cfg.Compress || w.cfg.MaxBackups > 0 || w.cfg.MaxAge > 0 {
+		w.cleanupWG.Add(1)
+		go func() {
+			defer w.cleanupWG.Done()
+			if err := w.cleanup(); err != nil {
+				slog.Error("Rotated file cleanup failed", "path", w.cfg.Path, "error", err)
+			}
+		}()
+	}
+	return nil
+}
+
+func (w *Writer) backupExists(name string) bool {
+	for _, path := range []string{name, name + compressSuffix} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanup enforces the retention limits, then compresses the remaining backups.
+// Each pass covers every backup, so files left uncompressed by a previous run are caught up.
+func (w *Writer) cleanup() error {
+	w.cleanupMu.Lock()
+	defer w.cleanupMu.Unlock()
+
+	backups, err := Backups(w.cfg.Path)
+	if err != nil {
+		return err
+	}
+	for i, path := range backups {
+		expired := w.cfg.MaxBackups > 0 && i < len(backups)-w.cfg.MaxBackups
+		if !expired && w.cfg.MaxAge > 0 {
+			if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > w.cfg.MaxAge {
+				expired = true
+			}
+		}
+		if expired {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("rotate: %w", err)
+			}
+			continue
+		}
+		if w.cfg.Compress && !strings.HasSuffix(path, compressSuffix) {
+			if err := compressFile(path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// compressFile replaces path with path.gz, keeping its modification time for MaxAge
+func compressFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("rotate: %w", err)
+	}
+	defer src.Close()
+	info, err := src.Stat()
+	if err != nil {
+		return fmt.Errorf("rotate: %w", err)
+	}
+
+	// Write to a temporary name so a partial archive is never taken for a backup
+	tmp := path + compressSuffix + ".tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("rotate: %w", err)
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chtimes(tmp, info.ModTime(), info.ModTime())
+	}
+	if err == nil {
+		err = os.Rename(tmp, path+compressSuffix)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("rotate: compress %s: %w", path, err)
+	}
+	return os.Remove(path)
+}
+
+// Close closes the current file and waits for background compression and retention
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	var err error
+	if w.file != nil {
+		err = w.file.Close()
+		w.file = nil
+	}
+	w.mu.Unlock()
+
+	w.cleanupWG.Wait()
+	return err
+}
+
+// BackupName returns the name of the file path rotated at t
+func BackupName(path string, t time.Time) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + t.UTC().Format(backupTimeFormat) + ext
+}
+
+// Backups lists the rotated files of path, compressed or not, oldest first
+func Backups(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	prefix := strings.TrimSuffix(path, ext) + "-"
+
+	matches, err := filepath.Glob(globEscape(prefix) + "*" + globEscape(ext) + "*")
+	if err != nil {
+		return nil, fmt.Errorf("rotate: %w", err)
+	}
+
+	var backups []string
+	for _, match := range matches {
+		rest := strings.TrimPrefix(match, prefix)
+		stamp := rest[:min(len(rest), len(backupTimeFormat))]
+		suffix := strings.TrimPrefix(rest, stamp)
+		if suffix != ext && suffix != ext+compressSuffix {
+			continue
+		}
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, match)
+		}
+	}
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// globEscape escapes glob metacharacters of a literal path
+func globEscape(s string) string {
+	var sb strings.Builder
+	for _, c := range s {
+		if strings.ContainsRune(`*?[\`, c) {
+			sb.WriteByte('\\')
+		}
+		sb.WriteRune(c)
+	}
+	return sb.String()
+}
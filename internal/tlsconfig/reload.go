@@ -0,0 +1,182 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Reloader is a file-backed TLS resource that can be reloaded when its files change
+type Reloader interface {
+	// Name identifies the resource in logs
+	Name() string
+	// Reload re-reads the files if they changed since the last load.
+	// It reports whether a new version was loaded.
+	Reload() (bool, error)
+}
+
+// KeyPair is a certificate/key file pair reloaded when either file changes.
+// On reload failure the previously loaded certificate stays in use.
+type KeyPair struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.RWMutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+// LoadKeyPair loads a PEM certificate and private key
+func LoadKeyPair(certFile, keyFile string) (*KeyPair, error) {
+	k := &KeyPair{certFile: certFile, keyFile: keyFile}
+	if _, err := k.Reload(); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Name returns the certificate file path
+func (k *KeyPair) Name() string {
+	return k.certFile
+}
+
+// Certificate returns the currently loaded certificate
+func (k *KeyPair) Certificate() *tls.Certificate {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.cert
+}
+
+// Reload re-reads the key pair if either file changed
+func (k *KeyPair) Reload() (bool, error) {
+	modTime, err := latestModTime(k.certFile, k.keyFile)
+	if err != nil {
+		return false, err
+	}
+
+	k.mu.RLock()
+	unchanged := k.cert != nil && modTime.Equal(k.modTime)
+	k.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(k.certFile, k.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("load key pair %s: %w", k.certFile, err)
+	}
+
+	k.mu.Lock()
+	k.cert = &cert
+	k.modTime = modTime
+	k.mu.Unlock()
+	return true, nil
+}
+
+// CertPool is a PEM CA bundle reloaded when the file changes
+type CertPool struct {
+	file string
+
+	mu      sync.RWMutex
+	pool    *x509.CertPool
+	modTime time.Time
+}
+
+// LoadCertPool loads a PEM CA bundle
+func LoadCertPool(file string) (*CertPool, error) {
+	p := &CertPool{file: file}
+	if _, err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Name returns the CA bundle file path
+func (p *CertPool) Name() string {
+	return p.file
+}
+
+// Pool returns the currently loaded CA pool
+func (p *CertPool) Pool() *x509.CertPool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.pool
+}
+
+// Reload re-reads the CA bundle if the file changed
+func (p *CertPool) Reload() (bool, error) {
+	modTime, err := latestModTime(p.file)
+	if err != nil {
+		return false, err
+	}
+
+	p.mu.RLock()
+	unchanged := p.pool != nil && modTime.Equal(p.modTime)
+	p.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	data, err := os.ReadFile(p.file)
+	if err != nil {
+		return false, err
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return false, fmt.Errorf("no certificates found in %s", p.file)
+	}
+
+	p.mu.Lock()
+	p.pool = pool
+	p.modTime = modTime
+	p.mu.Unlock()
+	return true, nil
+}
+
+// Watch polls the given resources every interval until ctx is done.
+// onReload is called after every reload attempt that loaded new files or failed.
+func Watch(ctx context.Context, interval time.Duration, onReload func(name string, err error), items ...Reloader) {
+	if len(items) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, item := range items {
+				changed, err := item.Reload()
+				if (changed || err != nil) && onReload != nil {
+					onReload(item.Name(), err)
+				}
+			}
+		}
+	}
+}
+
+// latestModTime returns the most recent modification time of the given files
+func latestModTime(files ...string) (time.Time, error) {
+	var latest time.Time
+	for _, file := range files {
+		if file == "" {
+			return time.Time{}, errors.New("empty file path")
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
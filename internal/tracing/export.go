@@ -0,0 +1,213 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Exporter sends finished spans to a tracing backend
+type Exporter interface {
+	// Export sends a batch of spans of the given service
+	Export(ctx context.Context, service string, spans []SpanData) error
+	// Shutdown releases the exporter resources
+	Shutdown(ctx context.Context) error
+}
+
+// Batching of exported spans
+const (
+	queueSize     = 2048
+	maxBatchSize  = 256
+	flushInterval = 5 * time.Second
+	exportTimeout = 10 * time.Second
+)
+
+// batcher queues finished spans and exports them in batches from one goroutine,
+// so requests never wait on the exporter. Spans are dropped when the queue is full.
+type batcher struct {
+	service  string
+	exporter Exporter
+
+	queue   chan SpanData
+	done    chan struct{}
+	stopped sync.WaitGroup
+	once    sync.Once
+	dropped atomic.Int64
+}
+
+func newBatcher(service string, exporter Exporter) *batcher {
+	b := &batcher{
+		service:  service,
+		exporter: exporter,
+		queue:    make(chan SpanData, queueSize),
+		done:     make(chan struct{}),
+	}
+	b.stopped.Add(1)
+	go b.run()
+	return b
+}
+
+func (b *batcher) enqueue(span SpanData) {
+	select {
+	case b.queue <- span:
+	default:
+		if b.dropped.Add(1)%1000 == 1 {
+			slog.Warn("Trace export queue full, dropping spans", "dropped", b.dropped.Load())
+		}
+	}
+}
+
+func (b *batcher) run() {
+	defer b.stopped.Done()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, maxBatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		if err := b.exporter.Export(ctx, b.service, batch); err != nil {
+			slog.Warn("Trace export failed", "spans", len(batch), "error", err)
+		}
+		cancel()
+		batch = make([]SpanData, 0, maxBatchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case span := <-b.queue:
+				batch = append(batch, span)
+				if len(batch) == maxBatchSize {
+					export()
+				}
+			default:
+				export()
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case span := <-b.queue:
+			batch = append(batch, span)
+			if len(batch) == maxBatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case <-b.done:
+			drain()
+			return
+		}
+	}
+}
+
+// shutdown exports the queued spans, then shuts the exporter down
+func (b *batcher) shutdown(ctx context.Context) error {
+	b.once.Do(func() { close(b.done) })
+
+	stopped := make(chan struct{})
+	go func() {
+		b.stopped.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return b.exporter.Shutdown(ctx)
+}
+
+// writerExporter writes one JSON object per span, for development (stdout or a file)
+type writerExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter creates an exporter writing spans as JSON lines to w.
+// w is closed on shutdown if it is an io.Closer other than stdout.
+func NewWriterExporter(w io.Writer) Exporter {
+	return &writerExporter{w: w}
+}
+
+// writerSpan is the JSON line written by the writer exporter
+type writerSpan struct {
+	Service       string                 `json:"service"`
+	Name          string                 `json:"name"`
+	Kind          string                 `json:"kind"`
+	TraceID       string                 `json:"trace_id"`
+	SpanID        string                 `json:"span_id"`
+	ParentSpanID  string                 `json:"parent_span_id,omitempty"`
+	Start         time.Time              `json:"start"`
+	DurationMs    float64                `json:"duration_ms"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Status        string                 `json:"status"`
+	StatusMessage string                 `json:"status_message,omitempty"`
+}
+
+func (e *writerExporter) Export(_ context.Context, service string, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	enc := json.NewEncoder(e.w)
+	for _, span := range spans {
+		line := writerSpan{
+			Service:       service,
+			Name:          span.Name,
+			Kind:          span.Kind.String(),
+			TraceID:       span.SpanContext.TraceID.String(),
+			SpanID:        span.SpanContext.SpanID.String(),
+			Start:         span.Start,
+			DurationMs:    float64(span.End.Sub(span.Start).Microseconds()) / 1000,
+			Attributes:    span.Attributes,
+			Status:        span.StatusCode.String(),
+			StatusMessage: span.StatusMessage,
+		}
+		if span.Parent.IsValid() {
+			line.ParentSpanID = span.Parent.String()
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *writerExporter) Shutdown(context.Context) error {
+	if c, ok := e.w.(io.Closer); ok && e.w != io.Writer(os.Stdout) {
+		return c.Close()
+	}
+	return nil
+}
+
+func (k SpanKind) String() string {
+	switch k {
+	case SpanKindServer:
+		return "server"
+	case SpanKindClient:
+		return "client"
+	default:
+		return "internal"
+	}
+}
+
+func (c StatusCode) String() string {
+	switch c {
+	case StatusOK:
+		return "ok"
+	case StatusError:
+		return "error"
+	default:
+		return "unset"
+	}
+}
@@ -0,0 +1,106 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOTLPExportOnShutdown(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []otlpTraceRequest
+		headers  []http.Header
+	)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req otlpTraceRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		requests = append(requests, req)
+		headers = append(headers, r.Header.Clone())
+		mu.Unlock()
+	}))
+	defer collector.Close()
+
+	tracer := NewTracer("gateway-test", NewOTLPExporter(collector.URL, map[string]string{"X-Api-Key": "secret"}), 1)
+
+	ctx, parent := tracer.Start(context.Background(), "GET /api/v1/users/{id}", SpanKindServer)
+	parent.SetAttribute("http.response.status_code", 200)
+	_, child := tracer.Start(ctx, "user.v1.UserService/GetUser", SpanKindClient)
+	child.SetStatus(StatusError, "unavailable")
+	child.End()
+	parent.End()
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := tracer.Shutdown(shutdownCtx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 1 {
+		t.Fatalf("collector received %d requests, want 1", len(requests))
+	}
+	if got := headers[0].Get("X-Api-Key"); got != "secret" {
+		t.Errorf("X-Api-Key = %q, want %q", got, "secret")
+	}
+	if got := headers[0].Get("Content-Type"); got != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got)
+	}
+
+	req := requests[0]
+	if len(req.ResourceSpans) != 1 || len(req.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected request shape: %+v", req)
+	}
+	var service string
+	for _, attr := range req.ResourceSpans[0].Resource.Attributes {
+		if attr.Key == "service.name" && attr.Value.StringValue != nil {
+			service = *attr.Value.StringValue
+		}
+	}
+	if service != "gateway-test" {
+		t.Errorf("service.name = %q, want gateway-test", service)
+	}
+
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	byName := make(map[string]otlpSpan)
+	for _, span := range spans {
+		byName[span.Name] = span
+	}
+	server, client := byName["GET /api/v1/users/{id}"], byName["user.v1.UserService/GetUser"]
+	if server.TraceID == "" || client.TraceID != server.TraceID {
+		t.Errorf("trace IDs differ: server %q, client %q", server.TraceID, client.TraceID)
+	}
+	if client.ParentSpanID != server.SpanID {
+		t.Errorf("client parentSpanId = %q, want %q", client.ParentSpanID, server.SpanID)
+	}
+	if server.Kind != SpanKindServer || client.Kind != SpanKindClient {
+		t.Errorf("kinds = %d, %d", server.Kind, client.Kind)
+	}
+	if client.Status.Code != StatusError || client.Status.Message != "unavailable" {
+		t.Errorf("client status = %+v", client.Status)
+	}
+}
+
+func TestOTLPExportCollectorError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	err := NewOTLPExporter(collector.URL, nil).Export(context.Background(), "gateway-test", []SpanData{{Name: "span"}})
+	if err == nil {
+		t.Fatal("Export succeeded, want an error for a 503 from the collector")
+	}
+}
@@ -0,0 +1,54 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// W3C trace context keys, lowercase as gRPC metadata requires
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// UnaryClientInterceptor starts a client span for every call to backend and
+// propagates it to the backend as traceparent/tracestate metadata
+func (t *Tracer) UnaryClientInterceptor(backend string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		name := strings.TrimPrefix(method, "/")
+		ctx, span := t.Start(ctx, name, SpanKindClient)
+		defer span.End()
+
+		sc := span.SpanContext()
+		ctx = metadata.AppendToOutgoingContext(ctx, TraceparentHeader, sc.Traceparent())
+		if sc.TraceState != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, TracestateHeader, sc.TraceState)
+		}
+
+		var p peer.Peer
+		err := invoker(ctx, method, req, reply, cc, append(opts, grpc.Peer(&p))...)
+
+		service, rpcMethod := name, ""
+		if i := strings.LastIndex(name, "/"); i >= 0 {
+			service, rpcMethod = name[:i], name[i+1:]
+		}
+		st := status.Convert(err)
+		span.SetAttribute("rpc.system", "grpc")
+		span.SetAttribute("rpc.service", service)
+		span.SetAttribute("rpc.method", rpcMethod)
+		span.SetAttribute("rpc.grpc.status_code", int(st.Code()))
+		span.SetAttribute("peer.service", backend)
+		if p.Addr != nil {
+			span.SetAttribute("server.address", p.Addr.String())
+		}
+		if err != nil {
+			span.SetStatus(StatusError, st.Message())
+		}
+		return err
+	}
+}
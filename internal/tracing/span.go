@@ -0,0 +1,174 @@
+package tracing
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// SpanKind describes the relationship of a span to its remote peer (OTLP values)
+type SpanKind int
+
+const (
+	SpanKindInternal SpanKind = 1
+	SpanKindServer   SpanKind = 2
+	SpanKindClient   SpanKind = 3
+)
+
+// StatusCode is the outcome of a span (OTLP values)
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// SpanData is a finished span handed to exporters
+type SpanData struct {
+	Name          string
+	Kind          SpanKind
+	SpanContext   SpanContext
+	Parent        SpanID // Zero for root spans
+	Start         time.Time
+	End           time.Time
+	Attributes    map[string]interface{} // string, bool, int, int64 or float64 values
+	StatusCode    StatusCode
+	StatusMessage string
+}
+
+// Span is an operation in progress. A nil or unsampled span ignores every call,
+// so callers never need to check whether tracing is enabled.
+type Span struct {
+	tracer *Tracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SpanContext returns the propagated identity of the span
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+// SetAttribute records an attribute (string, bool, int, int64 or float64)
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+// SetStatus records the outcome of the span
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.StatusCode = code
+	s.data.StatusMessage = message
+}
+
+// End finishes the span and queues it for export; later calls are ignored
+func (s *Span) End() {
+	if !s.recording() {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.exporter.enqueue(data)
+}
+
+func (s *Span) recording() bool {
+	return s != nil && s.tracer != nil && s.data.SpanContext.Sampled()
+}
+
+// Tracer starts spans and exports the sampled ones
+type Tracer struct {
+	service     string
+	exporter    *batcher
+	sampleRatio float64
+}
+
+// NewTracer creates a tracer for the given service name. Root spans are sampled with
+// sampleRatio (0..1); child spans follow the sampling decision of their parent.
+func NewTracer(service string, exporter Exporter, sampleRatio float64) *Tracer {
+	return &Tracer{
+		service:     service,
+		exporter:    newBatcher(service, exporter),
+		sampleRatio: math.Max(0, math.Min(1, sampleRatio)),
+	}
+}
+
+// Start starts a span as a child of the span (or remote parent) found in ctx and
+// returns a context carrying it. A nil tracer returns a nil span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	parent := SpanContextFromContext(ctx)
+	sc := SpanContext{SpanID: newSpanID()}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		sc.TraceID = newTraceID()
+		if t.sampled(sc.TraceID) {
+			sc.Flags = flagSampled
+		}
+	}
+
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:        name,
+			Kind:        kind,
+			SpanContext: sc,
+			Start:       time.Now(),
+			Attributes:  make(map[string]interface{}),
+		},
+	}
+	if parent.IsValid() {
+		span.data.Parent = parent.SpanID
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// Shutdown exports the queued spans and stops the exporter
+func (t *Tracer) Shutdown(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	return t.exporter.shutdown(ctx)
+}
+
+// sampled decides from the trace ID, so every service using the same ratio
+// keeps the same traces (like the OpenTelemetry TraceIDRatioBased sampler)
+func (t *Tracer) sampled(id TraceID) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	var x uint64
+	for _, b := range id[8:] {
+		x = x<<8 | uint64(b)
+	}
+	return float64(x>>1) < t.sampleRatio*float64(uint64(1)<<63)
+}
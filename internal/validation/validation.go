@@ -0,0 +1,344 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/thatlq1812/service-3-gateway/internal/i18n"
+	"github.com/thatlq1812/service-3-gateway/internal/response"
+)
+
+// Rules are declared on request struct fields with the validate tag, applied in this order:
+//
+//	trim        trim surrounding whitespace (the field is modified)
+//	omitempty   skip the other rules when the value is empty
+//	required    must not be empty (or zero)
+//	min=N       at least N characters, or at least N for numbers
+//	max=N       at most N characters, or at most N for numbers
+//	email       a valid email address
+//	password    must satisfy the password policy
+//
+// Rules can be replaced per deployment with Config.Rules, keyed "<Type>.<json field>".
+
+// PasswordPolicy is the strength policy of the password rule
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"` // In characters
+	MaxLength     int  `json:"max_length"` // In bytes (UTF-8), as bcrypt only uses the first 72 bytes
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+}
+
+// Config is the per-deployment validation configuration
+type Config struct {
+	Password PasswordPolicy    `json:"password"`
+	Rules    map[string]string `json:"rules"` // "<Type>.<json field>" -> validate tag
+}
+
+// DefaultConfig returns the policy used when no rules file is configured
+func DefaultConfig() Config {
+	return Config{
+		Password: PasswordPolicy{
+			MinLength:    8,
+			MaxLength:    72,
+			RequireUpper: true,
+			RequireLower: true,
+			RequireDigit: true,
+		},
+	}
+}
+
+// LoadConfig reads a JSON rules file over the defaults
+func LoadConfig(path string) (Config, error) {
+	cfg := DefaultConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, fmt.Errorf("validation: %w", err)
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("validation: %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// Validator applies the validate tags of request structs
+type Validator struct {
+	cfg   Config
+	cache sync.Map // reflect.Type -> []fieldRules
+}
+
+// fieldRules are the parsed rules of one struct field
+type fieldRules struct {
+	index     int
+	name      string
+	trim      bool
+	omitempty bool
+	required  bool
+	min, max  *float64
+	email     bool
+	password  bool
+}
+
+// New returns a validator, failing on malformed override rules
+func New(cfg Config) (*Validator, error) {
+	for key, tag := range cfg.Rules {
+		if _, err := parseRules(tag); err != nil {
+			return nil, fmt.Errorf("validation: rule %s: %w", key, err)
+		}
+	}
+	return &Validator{cfg: cfg}, nil
+}
+
+// Check fails when an override rule names a field of none of the given request types,
+// so a typo in the rules file is not silently ignored
+func (v *Validator) Check(reqs ...any) error {
+	known := make(map[string]bool)
+	for _, req := range reqs {
+		t := reflect.TypeOf(req)
+		for i := 0; i < t.NumField(); i++ {
+			known[t.Name()+"."+fieldName(t.Field(i))] = true
+		}
+	}
+	for key := range v.cfg.Rules {
+		if !known[key] {
+			return fmt.Errorf("validation: rule %s does not match a request field", key)
+		}
+	}
+	return nil
+}
+
+var defaultValidator atomic.Pointer[Validator]
+
+// SetDefault sets the validator used by Validate
+func SetDefault(v *Validator) {
+	defaultValidator.Store(v)
+}
+
+// Default returns the validator used by Validate, with DefaultConfig unless SetDefault was called
+func Default() *Validator {
+	if v := defaultValidator.Load(); v != nil {
+		return v
+	}
+	v, _ := New(DefaultConfig())
+	defaultValidator.CompareAndSwap(nil, v)
+	return defaultValidator.Load()
+}
+
+// Validate validates req, a pointer to a request struct, with the default validator
+func Validate(req any) []response.FieldError {
+	return Default().Validate(req)
+}
+
+// ValidateFields validates only the given json fields of req, e.g. the members present in a merge patch
+func ValidateFields(req any, fields ...string) []response.FieldError {
+	return Default().ValidateFields(req, fields...)
+}
+
+// Validate trims the fields of req, a pointer to a request struct, and returns every violation
+func (v *Validator) Validate(req any) []response.FieldError {
+	return v.validate(req, func(string) bool { return true })
+}
+
+// ValidateFields is Validate limited to the given json fields
+func (v *Validator) ValidateFields(req any, fields ...string) []response.FieldError {
+	return v.validate(req, func(name string) bool { return slices.Contains(fields, name) })
+}
+
+func (v *Validator) validate(req any, include func(name string) bool) []response.FieldError {
+	rv := reflect.ValueOf(req)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic("validation: Validate requires a pointer to a struct")
+	}
+	rv = rv.Elem()
+
+	var errs []response.FieldError
+	for _, rules := range v.rules(rv.Type()) {
+		if include(rules.name) {
+			errs = append(errs, v.validateField(rv.Field(rules.index), rules)...)
+		}
+	}
+	return errs
+}
+
+func (v *Validator) validateField(field reflect.Value, rules fieldRules) []response.FieldError {
+	var errs []response.FieldError
+	fail := func(rule, key string, params map[string]any) {
+		errs = append(errs, response.NewFieldError(rules.name, rule, key, params))
+	}
+
+	if rules.trim && field.Kind() == reflect.String {
+		field.SetString(strings.TrimSpace(field.String()))
+	}
+	if field.IsZero() {
+		if rules.required {
+			fail("required", i18n.FieldRequired, nil)
+		}
+		if rules.omitempty || rules.required {
+			return errs
+		}
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		value := field.String()
+		length := float64(utf8.RuneCountInString(value))
+		if rules.min != nil && length < *rules.min {
+			fail("minLength", i18n.FieldMinLength, map[string]any{"min": formatNumber(*rules.min)})
+		}
+		if rules.max != nil && length > *rules.max {
+			fail("maxLength", i18n.FieldMaxLength, map[string]any{"max": formatNumber(*rules.max)})
+		}
+		if rules.email {
+			if addr, err := mail.ParseAddress(value); err != nil || addr.Address != value {
+				fail("format", i18n.FieldEmail, nil)
+			}
+		}
+		if rules.password {
+			if key, params := v.checkPassword(value); key != "" {
+				fail("password", key, params)
+			}
+		}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value := float64(field.Int())
+		if rules.min != nil && value < *rules.min {
+			fail("minimum", i18n.FieldMinimum, map[string]any{"min": formatNumber(*rules.min)})
+		}
+		if rules.max != nil && value > *rules.max {
+			fail("maximum", i18n.FieldMaximum, map[string]any{"max": formatNumber(*rules.max)})
+		}
+	}
+	return errs
+}
+
+// checkPassword returns the message key and params of what the password is missing,
+// or "" if it satisfies the policy
+func (v *Validator) checkPassword(password string) (string, map[string]any) {
+	policy := v.cfg.Password
+	var missing []string
+	if n := utf8.RuneCountInString(password); n < policy.MinLength {
+		missing = append(missing, i18n.PasswordMinLength)
+	}
+	if policy.MaxLength > 0 && len(password) > policy.MaxLength {
+		return i18n.PasswordMaxBytes, map[string]any{"max": policy.MaxLength}
+	}
+
+	var upper, lower, digit, symbol bool
+	for _, c := range password {
+		switch {
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsDigit(c):
+			digit = true
+		case unicode.IsPunct(c) || unicode.IsSymbol(c) || unicode.IsSpace(c):
+			symbol = true
+		}
+	}
+	if policy.RequireUpper && !upper {
+		missing = append(missing, i18n.PasswordUpper)
+	}
+	if policy.RequireLower && !lower {
+		missing = append(missing, i18n.PasswordLower)
+	}
+	if policy.RequireDigit && !digit {
+		missing = append(missing, i18n.PasswordDigit)
+	}
+	if policy.RequireSymbol && !symbol {
+		missing = append(missing, i18n.PasswordSymbol)
+	}
+
+	if len(missing) == 0 {
+		return "", nil
+	}
+	return i18n.PasswordMissing, map[string]any{"missing": missing, "min": policy.MinLength}
+}
+
+// rules returns the parsed rules of a struct type, with the configured overrides
+func (v *Validator) rules(t reflect.Type) []fieldRules {
+	if cached, ok := v.cache.Load(t); ok {
+		return cached.([]fieldRules)
+	}
+
+	var all []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := fieldName(field)
+
+		tag, ok := v.cfg.Rules[t.Name()+"."+name]
+		if !ok {
+			tag = field.Tag.Get("validate")
+		}
+		if tag == "" {
+			continue
+		}
+
+		rules, err := parseRules(tag)
+		if err != nil {
+			panic(fmt.Sprintf("validation: %s.%s: %v", t.Name(), field.Name, err))
+		}
+		rules.index, rules.name = i, name
+		all = append(all, rules)
+	}
+
+	v.cache.Store(t, all)
+	return all
+}
+
+// fieldName is the json name of a field, or its Go name when it has none
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func parseRules(tag string) (fieldRules, error) {
+	var rules fieldRules
+	for _, rule := range strings.Split(tag, ",") {
+		name, arg, hasArg := strings.Cut(strings.TrimSpace(rule), "=")
+		switch name {
+		case "":
+		case "trim":
+			rules.trim = true
+		case "omitempty":
+			rules.omitempty = true
+		case "required":
+			rules.required = true
+		case "email":
+			rules.email = true
+		case "password":
+			rules.password = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(arg, 64)
+			if !hasArg || err != nil {
+				return rules, fmt.Errorf("%s needs a number, e.g. %s=10", name, name)
+			}
+			if name == "min" {
+				rules.min = &n
+			} else {
+				rules.max = &n
+			}
+		default:
+			return rules, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+	return rules, nil
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}